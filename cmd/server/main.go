@@ -3,16 +3,28 @@ package main
 import (
 	"context"
 	"fmt"
+	"gochi-boilerplate/db/migrations"
 	"gochi-boilerplate/internal/handler"
+	"gochi-boilerplate/internal/logging"
+	"gochi-boilerplate/internal/metrics"
 	"gochi-boilerplate/internal/middleware"
+	"gochi-boilerplate/internal/migrate"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/ratelimit"
 	"gochi-boilerplate/internal/repository"
+	"gochi-boilerplate/internal/tracing"
 	"gochi-boilerplate/internal/utils"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
 
 	_ "gochi-boilerplate/docs"
 
@@ -34,60 +46,225 @@ import (
 // @in header
 // @name Authorization
 func main() {
-	utils.LoadConfig()
-	dbURL := utils.GetEnv("DATABASE_URL", "")
-	port := utils.GetEnv("SERVER_PORT", "8080")
+	// Subcommand migrate hanya membutuhkan DATABASE_URL, jadi ditangani sebelum konfigurasi lengkap
+	// divalidasi agar migrasi di pipeline deploy tidak gagal karena JWT_SECRET atau mail belum diatur
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Migrasi gagal: %v\n", err)
+		}
+		return
+	}
+
+	cfg, err := utils.LoadConfig()
+	if err != nil {
+		log.Fatalf("Konfigurasi tidak valid:\n%v\n", err)
+	}
+
+	logger := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	slog.SetDefault(logger)
+
+	// run dipisah dari main agar defer (misalnya menutup pool database) tetap dijalankan
+	// sebelum proses keluar; os.Exit di dalam run akan melewati defer tersebut.
+	if err := run(cfg, logger); err != nil {
+		logger.Error("Server berhenti karena error", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg *utils.Config, logger *slog.Logger) error {
+	port := cfg.Server.Port
+
+	// ctx dibatalkan saat menerima SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if dbURL == "" {
-		log.Fatal("DATABASE_URL harus diatur di environment variable")
+	tracerProvider, err := setupTracing(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("Gagal menyiapkan tracing: %w", err)
+	}
+	if tracerProvider != nil {
+		defer shutdownTracing(tracerProvider, logger)
 	}
 
-	dbpool, err := pgxpool.New(context.Background(), dbURL)
+	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("Tidak bisa terhubung ke database: %v\n", err)
+		return fmt.Errorf("DATABASE_URL tidak valid: %w", err)
+	}
+	if tracerProvider != nil {
+		// Setiap query repository tercatat sebagai child span dari span request
+		poolConfig.ConnConfig.Tracer = tracing.NewQueryTracer(tracerProvider)
+	}
+
+	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return fmt.Errorf("Tidak bisa terhubung ke database: %w", err)
 	}
 	defer dbpool.Close()
+	metrics.RegisterPool(dbpool)
+
+	migrator, err := migrate.New(dbpool, migrations.FS)
+	if err != nil {
+		return fmt.Errorf("Gagal membaca file migrasi: %w", err)
+	}
+
+	if cfg.MigrateOnStart {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("Migrasi gagal: %w", err)
+		}
+		logger.Info("Migrasi diterapkan saat start", "count", len(applied))
+	}
+
+	tokenManager, err := utils.NewTokenManagerFromConfig(cfg.JWT)
+	if err != nil {
+		return fmt.Errorf("Gagal memuat kunci JWT: %w", err)
+	}
 
 	// 2. Inisialisasi Repository dan Handler baru untuk User & Auth
 	userRepo := repository.NewUserRepository(dbpool)
 	productRepo := repository.NewProductRepository(dbpool)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(dbpool)
+	revocationStore := repository.NewRevocationStore(dbpool, cfg.RevocationCacheTTL)
 
-	authHandler := handler.NewAuthHandler(userRepo)
-	productHandler := handler.NewProductHandler(productRepo)
+	userTokenRepo := repository.NewUserTokenRepository(dbpool)
+
+	mail, err := newMailer(cfg.Mail)
+	if err != nil {
+		return fmt.Errorf("Gagal menyiapkan mailer: %w", err)
+	}
+
+	authHandler := handler.NewAuthHandler(cfg, userRepo, refreshTokenRepo, revocationStore, userTokenRepo, tokenManager, mail)
+	adminHandler := handler.NewAdminHandler(authHandler)
+	jwksHandler := handler.NewJWKSHandler(tokenManager)
+	authMiddleware := middleware.AuthMiddleware(tokenManager, revocationStore)
+	productHandler := handler.NewProductHandler(productRepo, cfg.RequireIfMatch)
+
+	// Produk di trash dihapus permanen di background setelah masa simpannya habis
+	if cfg.Trash.RetentionDays > 0 {
+		go runTrashPurge(ctx, productRepo, cfg.Trash.Retention(), cfg.Trash.PurgeInterval, logger)
+	}
+	healthHandler := handler.NewHealthHandler(dbpool, migrator)
+
+	// limit membuat middleware rate limit untuk satu grup rute; tanpa efek jika rate limit dimatikan
+	rateLimitStore := ratelimit.NewMemoryStore()
+	limit := func(name string, l model.RateLimit, key ratelimit.KeyFunc) func(http.Handler) http.Handler {
+		if !cfg.RateLimit.Enabled {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return ratelimit.Middleware(rateLimitStore, name, l, key)
+	}
 
 	r := chi.NewRouter()
 
 	// Middleware Global
-	r.Use(chiMiddleware.Logger)
+	r.Use(logging.Middleware(logger))
+	if tracerProvider != nil {
+		r.Use(tracing.Middleware(tracerProvider, otel.GetTextMapPropagator()))
+	}
+	r.Use(metrics.Middleware)
 	r.Use(chiMiddleware.Recoverer)
 
+	scheme := "http"
+	if cfg.Server.TLSEnabled() {
+		scheme = "https"
+	}
+
 	// Rute Swagger (Publik)
-	swaggerURL := fmt.Sprintf("http://localhost:%s/swagger/doc.json", port)
+	swaggerURL := fmt.Sprintf("%s://localhost:%s/swagger/doc.json", scheme, port)
 	r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(swaggerURL)))
 
+	// Metrik Prometheus (Publik, sebaiknya dibatasi di level jaringan/ingress)
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	// Probe liveness dan readiness untuk orchestrator (Publik)
+	r.Get("/healthz", healthHandler.Liveness)
+	r.Get("/readyz", healthHandler.Readiness)
+
+	// Kunci publik JWT untuk layanan lain (Publik)
+	r.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// 3. Rute Publik untuk Autentikasi
 	r.Route("/auth", func(r chi.Router) {
-		r.Post("/register", authHandler.Register)
-		r.Post("/login", authHandler.Login)
+		// Batas per IP untuk rute publik yang menjalankan bcrypt, bisa dipakai menebak password, atau menebak token
+		r.Group(func(r chi.Router) {
+			r.Use(limit("auth", cfg.RateLimit.Auth, ratelimit.ByIP))
+			r.Post("/register", authHandler.Register)
+			r.Post("/login", authHandler.Login)
+			r.Post("/refresh", authHandler.Refresh)
+			r.Post("/verify-email", authHandler.VerifyEmail)
+			r.Post("/confirm-email-change", authHandler.ConfirmEmailChange)
+			r.Post("/reset-password", authHandler.ResetPassword)
+		})
+
+		// Rute yang mengirim email ke alamat pilihan pemanggil memakai bucket tersendiri yang lebih ketat,
+		// agar tidak bisa dipakai membanjiri kotak masuk orang lain
+		r.Group(func(r chi.Router) {
+			r.Use(limit("mail", cfg.RateLimit.Mail, ratelimit.ByIP))
+			r.Post("/resend-verification", authHandler.ResendVerification)
+			r.Post("/forgot-password", authHandler.ForgotPassword)
+		})
+
+		// Logout memerlukan access token yang masih valid
+		r.Group(func(r chi.Router) {
+			r.Use(authMiddleware)
+			r.Use(limit("api", cfg.RateLimit.API, ratelimit.ByUser))
+			r.Post("/logout", authHandler.Logout)
+			r.Post("/logout-all", authHandler.LogoutAll)
+		})
+	})
+
+	// Pengelolaan pengguna, khusus admin
+	r.Route("/admin/users", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(middleware.RequireAdmin)
+		r.Use(limit("api", cfg.RateLimit.API, ratelimit.ByUser))
+		r.Get("/", adminHandler.ListUsers)
+		r.Get("/{id}", adminHandler.GetUser)
+		r.Put("/{id}/role", adminHandler.UpdateRole)
+		r.Post("/{id}/disable", adminHandler.DisableUser)
+		r.Post("/{id}/enable", adminHandler.EnableUser)
+		r.Post("/{id}/force-password-reset", adminHandler.ForcePasswordReset)
+		r.Post("/{id}/impersonate", adminHandler.Impersonate)
+	})
+
+	// Profil pengguna yang sedang login
+	r.Route("/me", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Use(limit("api", cfg.RateLimit.API, ratelimit.ByUser))
+		r.Get("/", authHandler.GetMe)
+		r.Patch("/", authHandler.UpdateMe)
+		r.Delete("/", authHandler.DeleteMe)
+		r.Post("/password", authHandler.ChangePassword)
 	})
 
 	// 4. Grup Rute Terproteksi yang memerlukan JWT
 	r.Group(func(r chi.Router) {
 		// Gunakan AuthMiddleware di sini untuk melindungi semua rute di dalam grup ini
-		r.Use(middleware.AuthMiddleware)
+		r.Use(authMiddleware)
+		r.Use(limit("api", cfg.RateLimit.API, ratelimit.ByUser))
 
 		// Rute untuk produk sekarang berada di dalam grup yang dilindungi
+		// Setiap rute mendeklarasikan hak akses yang dibutuhkan (lihat model.RolePermissions)
 		r.Route("/products", func(r chi.Router) {
-			r.Post("/", productHandler.CreateProduct)
-			r.Get("/", productHandler.GetAllProducts)
-			r.Get("/{id}", productHandler.GetProductByID)
-			r.Put("/{id}", productHandler.UpdateProduct)
-			r.Delete("/{id}", productHandler.DeleteProduct)
+			r.With(middleware.RequirePermission(model.PermProductsCreate)).Post("/", productHandler.CreateProduct)
+			r.With(middleware.RequirePermission(model.PermProductsRead)).Get("/", productHandler.GetAllProducts)
+			r.With(middleware.RequirePermission(model.PermProductsRead)).Get("/search", productHandler.SearchProducts)
+			r.With(middleware.RequirePermission(model.PermProductsDelete)).Get("/trash", productHandler.ListTrash)
+			r.With(middleware.RequirePermission(model.PermProductsRead)).Get("/{id}", productHandler.GetProductByID)
+			r.With(middleware.RequirePermission(model.PermProductsUpdate)).Put("/{id}", productHandler.UpdateProduct)
+			r.With(middleware.RequirePermission(model.PermProductsUpdate)).Patch("/{id}", productHandler.PatchProduct)
+			r.With(middleware.RequirePermission(model.PermProductsDelete)).Delete("/{id}", productHandler.DeleteProduct)
+			r.With(middleware.RequirePermission(model.PermProductsDelete)).Post("/{id}/restore", productHandler.RestoreProduct)
+			r.With(middleware.RequirePermission(model.PermProductsPurge)).Delete("/{id}/permanent", productHandler.PurgeProduct)
 		})
 	})
 
-	// Menjalankan Server
-	fmt.Printf("Server berjalan di port %s\n", port)
-	fmt.Printf("Swagger UI tersedia di http://localhost:%s/swagger/index.html\n", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
-}
\ No newline at end of file
+	// Menjalankan Server sampai menerima sinyal berhenti
+	srv := newHTTPServer(cfg.Server, r, logger)
+	logger.Info("Server berjalan",
+		"port", port,
+		"tls", cfg.Server.TLSEnabled(),
+		"swagger_url", fmt.Sprintf("%s://localhost:%s/swagger/index.html", scheme, port),
+	)
+	return serve(ctx, srv, cfg.Server, logger, healthHandler.SetShuttingDown)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gochi-boilerplate/db/migrations"
+	"gochi-boilerplate/internal/migrate"
+	"gochi-boilerplate/internal/utils"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const migrateUsage = `Penggunaan: server migrate <perintah>
+
+Perintah:
+  up               Menerapkan semua migrasi yang belum diterapkan
+  down [N]         Membatalkan N migrasi terakhir (default 1)
+  status           Menampilkan status setiap migrasi
+  force <VERSION>  Menandai migrasi sampai VERSION sebagai sudah diterapkan tanpa menjalankan SQL`
+
+// runMigrateCommand menjalankan subcommand "migrate" dengan koneksi database sendiri. Hanya
+// DATABASE_URL yang dibaca; tracing dan konfigurasi server lainnya tidak disiapkan.
+func runMigrateCommand(args []string) error {
+	databaseURL, err := utils.LoadDatabaseURL()
+	if err != nil {
+		return err
+	}
+
+	// ctx dibatalkan saat menerima SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return fmt.Errorf("DATABASE_URL tidak valid: %w", err)
+	}
+	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return fmt.Errorf("Tidak bisa terhubung ke database: %w", err)
+	}
+	defer dbpool.Close()
+
+	migrator, err := migrate.New(dbpool, migrations.FS)
+	if err != nil {
+		return fmt.Errorf("Gagal membaca file migrasi: %w", err)
+	}
+	return runMigrate(ctx, migrator, args)
+}
+
+// runMigrate menjalankan subcommand "migrate" dan mengembalikan error jika gagal
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Diterapkan: %06d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("Tidak ada migrasi baru")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("jumlah langkah harus bilangan bulat positif, didapat %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("Dibatalkan: %06d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSI\tNAMA\tDITERAPKAN")
+		for _, s := range statuses {
+			applied := "belum"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(tw, "%06d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return tw.Flush()
+
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("force membutuhkan nomor versi")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("versi harus bilangan bulat, didapat %q", args[1])
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Printf("Versi migrasi dipaksa ke %d\n", version)
+		return nil
+
+	default:
+		return fmt.Errorf("perintah migrate tidak dikenal %q\n\n%s", args[0], migrateUsage)
+	}
+}
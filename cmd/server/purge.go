@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"gochi-boilerplate/internal/repository"
+	"log/slog"
+	"time"
+)
+
+// runTrashPurge menghapus permanen produk yang sudah lebih lama dari retention di trash, sekali saat
+// start lalu setiap interval, sampai ctx dibatalkan. Kegagalan hanya dicatat di log dan dicoba lagi
+// pada putaran berikutnya.
+func runTrashPurge(ctx context.Context, products repository.ProductStore, retention, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := products.PurgeTrashedProducts(ctx, time.Now().Add(-retention))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			logger.Error("Gagal menghapus produk lama di trash", "error", err)
+		case purged > 0:
+			logger.Info("Produk lama di trash dihapus permanen", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
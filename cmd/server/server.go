@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/mailer"
+	"gochi-boilerplate/internal/tracing"
+	"gochi-boilerplate/internal/utils"
+	"log/slog"
+	"net/http"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newHTTPServer membuat http.Server dengan batas waktu dan ukuran header dari konfigurasi,
+// menggantikan http.ListenAndServe yang tidak memiliki timeout sama sekali
+func newHTTPServer(cfg utils.ServerConfig, handler http.Handler, logger *slog.Logger) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn), // Error koneksi (misal TLS handshake) ikut berformat slog
+	}
+}
+
+// serve menjalankan srv sampai ctx dibatalkan (SIGINT/SIGTERM), lalu menghentikannya dengan graceful:
+// beforeShutdown dipanggil (misalnya untuk menandai readiness gagal), server tetap melayani selama
+// cfg.ShutdownDelay agar load balancer sempat melepas instance ini, kemudian listener ditutup dan
+// request yang sedang berjalan ditunggu selesai paling lama cfg.ShutdownTimeout.
+func serve(ctx context.Context, srv *http.Server, cfg utils.ServerConfig, logger *slog.Logger, beforeShutdown func()) error {
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.TLSEnabled() {
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		// Server berhenti sendiri sebelum ada sinyal, misalnya port sudah dipakai
+		return fmt.Errorf("Server berhenti: %w", err)
+	case <-ctx.Done():
+	}
+
+	if beforeShutdown != nil {
+		beforeShutdown()
+	}
+	if cfg.ShutdownDelay > 0 {
+		logger.Info("Sinyal berhenti diterima, readiness dimatikan", "delay", cfg.ShutdownDelay.String())
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	logger.Info("Menghentikan server, menunggu request selesai", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// Batas waktu habis: putus paksa koneksi yang tersisa
+		srv.Close()
+		return fmt.Errorf("Shutdown tidak selesai dalam %s: %w", cfg.ShutdownTimeout, err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("Server berhenti: %w", err)
+	}
+
+	logger.Info("Server berhenti dengan bersih")
+	return nil
+}
+
+// setupTracing membuat dan memasang tracer provider global sesuai konfigurasi.
+// Mengembalikan nil jika tracing dimatikan.
+func setupTracing(ctx context.Context, cfg utils.TracingConfig) (*sdktrace.TracerProvider, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	exporter, err := tracing.NewExporter(ctx, cfg.Exporter)
+	if err != nil {
+		return nil, err
+	}
+	tp := tracing.NewProvider(exporter, cfg.ServiceName, cfg.SampleRatio)
+	tracing.SetGlobal(tp)
+	return tp, nil
+}
+
+// shutdownTracing mengirim span yang masih tertahan di batcher sebelum proses keluar
+func shutdownTracing(tp *sdktrace.TracerProvider, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tp.Shutdown(ctx); err != nil {
+		logger.Warn("Gagal mengirim sisa span tracing", "error", err)
+	}
+}
+
+// newMailer memilih implementasi Mailer sesuai MAIL_DRIVER
+func newMailer(cfg utils.MailConfig) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return mailer.NewFileMailer(cfg.FileDir, cfg.From), nil
+	case "log":
+		return mailer.NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
@@ -1,14 +1,10 @@
--- Hapus objek database yang ada untuk memastikan skrip bisa dijalankan ulang
-DROP TABLE IF EXISTS products;
-DROP TABLE IF EXISTS users;
-DROP TYPE IF EXISTS user_role;
-
 -- Mengaktifkan ekstensi untuk generate UUID jika belum ada
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 -- Membuat tipe data ENUM untuk peran pengguna
 -- Ini memastikan kolom 'role' hanya bisa diisi dengan nilai yang sudah ditentukan.
-CREATE TYPE user_role AS ENUM ('admin', 'user');
+-- Hak akses tiap peran didefinisikan di aplikasi (model.RolePermissions).
+CREATE TYPE user_role AS ENUM ('admin', 'editor', 'auditor', 'user');
 
 -- 1. Tabel untuk data pengguna (users)
 CREATE TABLE users (
@@ -41,15 +37,3 @@ CREATE TABLE products (
 
 -- Membuat index pada foreign key untuk mempercepat query join
 CREATE INDEX idx_products_user_id ON products(user_id);
-
--- INSERT DATA SAMPLE (opsional)
--- Password di-hash menggunakan bcrypt untuk keamanan
--- Password 'OnlinePHP' di-hash menjadi '$2y$10$TN7zDKb1jY9Dmmi3JKujWebUXWdcSMQd5Pq5qHjA6jAeUWKECo9tG'
-INSERT INTO users (full_name, email, password, role) VALUES
-('Admin User', 'h2xkR@example.com', '$2y$10$TN7zDKb1jY9Dmmi3JKujWebUXWdcSMQd5Pq5qHjA6jAeUWKECo9tG', 'admin'),
-('User Biasa', 'l7bTg@example.com', '$2y$10$TN7zDKb1jY9Dmmi3JKujWebUXWdcSMQd5Pq5qHjA6jAeUWKECo9tG', 'user');
-
-INSERT INTO products (name, price, user_id) VALUES
-('Produk A', 10000, (SELECT id FROM users WHERE email = 'h2xkR@example.com')),
-('Produk B', 20000, (SELECT id FROM users WHERE email = 'h2xkR@example.com')),
-('Produk C', 15000, (SELECT id FROM users WHERE email = 'l7bTg@example.com'));
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- Tabel untuk refresh token (refresh_tokens)
+-- Yang disimpan hanya hash SHA-256 dari token, bukan token aslinya.
+-- Setiap login membuat 'family' baru; setiap rotasi menambah token baru di family yang sama.
+CREATE TABLE refresh_tokens (
+    id UUID     PRIMARY KEY     DEFAULT uuid_generate_v4(),
+    user_id     UUID            NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id   UUID            NOT NULL,                   -- Dipakai untuk mencabut seluruh rantai rotasi saat terdeteksi reuse
+    token_hash  VARCHAR(64)     UNIQUE NOT NULL,
+    expires_at  TIMESTAMPTZ     NOT NULL,
+    revoked_at  TIMESTAMPTZ,                                -- Terisi saat token dirotasi atau dicabut
+    replaced_by UUID,                                       -- ID token pengganti hasil rotasi
+    created_at  TIMESTAMPTZ     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
@@ -0,0 +1,15 @@
+-- Daftar access token (JWT) yang dicabut sebelum kedaluwarsa, berdasarkan klaim jti
+CREATE TABLE revoked_tokens (
+    jti         VARCHAR(64)     PRIMARY KEY,
+    user_id     UUID            NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at  TIMESTAMPTZ     NOT NULL,                   -- Baris boleh dihapus setelah token kedaluwarsa
+    revoked_at  TIMESTAMPTZ     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Batas waktu logout-all: semua token pengguna yang terbit sebelum revoked_before dianggap dicabut
+CREATE TABLE user_token_revocations (
+    user_id         UUID        PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    revoked_before  TIMESTAMPTZ NOT NULL
+);
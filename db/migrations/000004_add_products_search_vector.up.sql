@@ -0,0 +1,7 @@
+-- Kolom pencarian full-text, dihitung otomatis oleh Postgres setiap kali nama produk berubah.
+-- Konfigurasi 'simple' dipakai agar tidak ada stemming khusus bahasa tertentu.
+ALTER TABLE products
+    ADD COLUMN search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('simple', coalesce(name, ''))) STORED;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
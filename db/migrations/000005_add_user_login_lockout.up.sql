@@ -0,0 +1,5 @@
+-- Penguncian akun setelah login gagal berulang kali.
+-- locked_until diisi dengan backoff eksponensial setelah failed_login_attempts mencapai ambang batas.
+ALTER TABLE users
+    ADD COLUMN failed_login_attempts INT NOT NULL DEFAULT 0 CHECK (failed_login_attempts >= 0),
+    ADD COLUMN locked_until          TIMESTAMPTZ;
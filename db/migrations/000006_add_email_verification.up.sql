@@ -0,0 +1,21 @@
+-- Verifikasi email dan reset password
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+-- Akun yang dibuat sebelum verifikasi email diperkenalkan dianggap sudah terverifikasi
+UPDATE users SET email_verified_at = created_at;
+
+CREATE TYPE user_token_purpose AS ENUM ('verify_email', 'reset_password');
+
+-- Token sekali pakai yang dikirim lewat email. Seperti refresh token, yang disimpan hanya hash SHA-256-nya.
+CREATE TABLE user_tokens (
+    id UUID     PRIMARY KEY         DEFAULT uuid_generate_v4(),
+    user_id     UUID                NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose     user_token_purpose  NOT NULL,
+    token_hash  VARCHAR(64)         UNIQUE NOT NULL,
+    email       VARCHAR(255)        NOT NULL,               -- Alamat tujuan token; token batal jika email pengguna sudah berubah
+    expires_at  TIMESTAMPTZ         NOT NULL,
+    used_at     TIMESTAMPTZ,                                -- Terisi saat token dipakai atau digantikan token baru
+    created_at  TIMESTAMPTZ         NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_tokens_user_id_purpose ON user_tokens(user_id, purpose);
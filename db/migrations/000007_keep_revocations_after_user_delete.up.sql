@@ -0,0 +1,3 @@
+-- Batas logout-all harus tetap ada setelah akun dihapus, agar access token milik akun tersebut
+-- yang belum kedaluwarsa tetap ditolak. Karena itu foreign key (yang ON DELETE CASCADE) dilepas.
+ALTER TABLE user_token_revocations DROP CONSTRAINT IF EXISTS user_token_revocations_user_id_fkey;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS admin_audit_logs;
+DROP TYPE IF EXISTS admin_action;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS password_reset_required,
+    DROP COLUMN IF EXISTS disabled_at;
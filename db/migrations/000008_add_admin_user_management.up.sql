@@ -0,0 +1,22 @@
+-- Pengelolaan pengguna oleh admin
+-- disabled_at: akun dinonaktifkan admin dan tidak bisa login sampai diaktifkan kembali.
+-- password_reset_required: login ditolak sampai pengguna mengganti password lewat tautan reset.
+ALTER TABLE users
+    ADD COLUMN disabled_at             TIMESTAMPTZ,
+    ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TYPE admin_action AS ENUM ('change_role', 'disable', 'enable', 'force_password_reset', 'impersonate');
+
+-- Jejak audit setiap tindakan admin terhadap akun pengguna. Sengaja tanpa foreign key
+-- agar catatan tetap ada setelah akun admin maupun akun target dihapus.
+CREATE TABLE admin_audit_logs (
+    id              UUID            PRIMARY KEY DEFAULT uuid_generate_v4(),
+    actor_id        UUID            NOT NULL,           -- Admin yang melakukan tindakan
+    action          admin_action    NOT NULL,
+    target_user_id  UUID            NOT NULL,
+    details         JSONB           NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ     NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_admin_audit_logs_target_user_id ON admin_audit_logs(target_user_id, created_at);
+CREATE INDEX idx_admin_audit_logs_actor_id ON admin_audit_logs(actor_id, created_at);
@@ -0,0 +1,5 @@
+-- Produk yang masih di trash dihapus permanen agar tidak muncul kembali setelah kolom deleted_at hilang
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_products_deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
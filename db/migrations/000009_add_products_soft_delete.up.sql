@@ -0,0 +1,6 @@
+-- Soft delete produk: DELETE dari API hanya mengisi deleted_at, produk masuk trash dan bisa dipulihkan.
+-- Produk di trash dihapus permanen oleh job purge setelah TRASH_RETENTION_DAYS hari.
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Dipakai oleh job purge; hanya berisi baris yang ada di trash
+CREATE INDEX idx_products_deleted_at ON products(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Nomor versi untuk optimistic locking: setiap perubahan produk menaikkan version, dan update/hapus
+-- hanya berhasil jika version masih sama dengan yang dibaca klien (dikirim lewat ETag/If-Match)
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1 CHECK (version > 0);
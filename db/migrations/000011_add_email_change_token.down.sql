@@ -0,0 +1,6 @@
+-- Nilai ENUM tidak bisa dihapus, jadi tipenya dibuat ulang tanpa 'change_email'
+DELETE FROM user_tokens WHERE purpose = 'change_email';
+ALTER TYPE user_token_purpose RENAME TO user_token_purpose_old;
+CREATE TYPE user_token_purpose AS ENUM ('verify_email', 'reset_password');
+ALTER TABLE user_tokens ALTER COLUMN purpose TYPE user_token_purpose USING purpose::text::user_token_purpose;
+DROP TYPE user_token_purpose_old;
@@ -0,0 +1,3 @@
+-- Token konfirmasi penggantian email. Email di token adalah alamat baru yang belum dipakai akun;
+-- email akun baru diganti setelah tautan yang dikirim ke alamat baru dibuka.
+ALTER TYPE user_token_purpose ADD VALUE 'change_email';
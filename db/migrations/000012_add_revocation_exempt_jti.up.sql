@@ -0,0 +1,4 @@
+-- Token yang dikecualikan dari batas logout-all, yaitu access token baru yang diterbitkan bersama
+-- pencabutan itu sendiri (misal saat ganti password). Dikecualikan lewat jti, bukan waktu terbit,
+-- karena klaim iat hanya berpresisi detik.
+ALTER TABLE user_token_revocations ADD COLUMN exempt_jti VARCHAR(64);
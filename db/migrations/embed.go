@@ -0,0 +1,11 @@
+// Package migrations menyimpan file migrasi SQL yang di-embed ke dalam binary.
+//
+// Format nama file: <versi>_<nama>.up.sql dan <versi>_<nama>.down.sql,
+// misal 000005_add_products_description.up.sql. Versi harus unik dan terus bertambah.
+// Jangan pernah mengubah file migrasi yang sudah dijalankan di production; buat migrasi baru.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
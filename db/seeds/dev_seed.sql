@@ -0,0 +1,14 @@
+-- DATA SAMPLE UNTUK DEVELOPMENT (jangan dijalankan di production)
+-- Jalankan setelah migrasi dengan: make db-seed
+-- Password di-hash menggunakan bcrypt untuk keamanan
+-- Password 'OnlinePHP' di-hash menjadi '$2y$10$TN7zDKb1jY9Dmmi3JKujWebUXWdcSMQd5Pq5qHjA6jAeUWKECo9tG'
+-- Email sample langsung ditandai terverifikasi agar bisa dipakai login
+INSERT INTO users (full_name, email, password, role, email_verified_at) VALUES
+('Admin User', 'h2xkR@example.com', '$2y$10$TN7zDKb1jY9Dmmi3JKujWebUXWdcSMQd5Pq5qHjA6jAeUWKECo9tG', 'admin', NOW()),
+('User Biasa', 'l7bTg@example.com', '$2y$10$TN7zDKb1jY9Dmmi3JKujWebUXWdcSMQd5Pq5qHjA6jAeUWKECo9tG', 'user', NOW())
+ON CONFLICT (email) DO NOTHING;
+
+INSERT INTO products (name, price, user_id) VALUES
+('Produk A', 10000, (SELECT id FROM users WHERE email = 'h2xkR@example.com')),
+('Produk B', 20000, (SELECT id FROM users WHERE email = 'h2xkR@example.com')),
+('Produk C', 15000, (SELECT id FROM users WHERE email = 'l7bTg@example.com'));
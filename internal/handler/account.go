@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"gochi-boilerplate/internal/logging"
+	"gochi-boilerplate/internal/mailer"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"gochi-boilerplate/internal/utils"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// mailTimeout membatasi lama pengiriman satu email di background
+const mailTimeout = 30 * time.Second
+
+// VerifyEmail godoc
+// @Summary      Verify an email address
+// @Description  Confirm ownership of an email address with the single-use token sent by email after registration.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body body model.VerifyEmailRequest true "Verification token from the email link"
+// @Success      200  {object}  utils.Response "Email verified"
+// @Failure      400  {object}  utils.Response "Invalid, used or expired token, or the email changed since the link was sent"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      429  {object}  utils.Response "Too many requests from this IP (code: rate_limited); see the Retry-After header"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req model.VerifyEmailRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	token, err := h.UserTokens.ConsumeUserToken(r.Context(), utils.HashToken(req.Token), model.TokenPurposeVerifyEmail)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(w, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa", "invalid or expired token")
+		return
+	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	err = h.UserRepo.MarkEmailVerified(r.Context(), token.UserID, token.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(w, http.StatusBadRequest, "Email akun sudah berubah sejak tautan dikirim", "email changed")
+		return
+	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, http.StatusOK, "Email berhasil diverifikasi", nil)
+}
+
+// ConfirmEmailChange godoc
+// @Summary      Confirm an email change
+// @Description  Switch the account to the new email address with the single-use token sent to that address by PATCH /me. The new address counts as verified, and a notice is sent to the old address.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body body model.VerifyEmailRequest true "Confirmation token from the email link"
+// @Success      200  {object}  utils.Response{data=model.User} "Email changed"
+// @Failure      400  {object}  utils.Response "Invalid, used or expired token"
+// @Failure      409  {object}  utils.Response "Email already used by another account (code: conflict)"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      429  {object}  utils.Response "Too many requests from this IP (code: rate_limited); see the Retry-After header"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /auth/confirm-email-change [post]
+func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req model.VerifyEmailRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	token, err := h.UserTokens.ConsumeUserToken(r.Context(), utils.HashToken(req.Token), model.TokenPurposeChangeEmail)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(w, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa", "invalid or expired token")
+		return
+	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), token.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(w, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa", "account no longer exists")
+		return
+	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	// Alamat baru sudah terbukti milik pengguna karena tautannya dibuka dari alamat tersebut
+	oldEmail := user.Email
+	now := time.Now()
+	user.Email, user.EmailVerifiedAt, user.UpdatedAt = token.Email, &now, now
+	if err := h.UserRepo.UpdateProfile(r.Context(), user); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if oldEmail != user.Email {
+		h.sendMail(r.Context(), mailer.EmailChangedNotice(oldEmail, user.FullName, user.Email))
+	}
+
+	utils.RespondSuccess(w, http.StatusOK, "Email berhasil diganti", user)
+}
+
+// ResendVerification godoc
+// @Summary      Resend the verification email
+// @Description  Send a new verification link if the email belongs to an unverified account. Earlier links stop working. The response is the same whether or not the email is registered.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body body model.EmailRequest true "Account email"
+// @Success      202  {object}  utils.Response "Accepted"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      429  {object}  utils.Response "Too many requests from this IP (code: rate_limited); see the Retry-After header"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req model.EmailRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByEmail(r.Context(), req.Email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err == nil && user.EmailVerifiedAt == nil {
+		if err := h.sendVerificationEmail(r.Context(), user); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	// Respon sama untuk email terdaftar maupun tidak, agar endpoint ini tidak bisa dipakai menebak akun
+	utils.RespondSuccess(w, http.StatusAccepted, "Jika email terdaftar dan belum diverifikasi, tautan verifikasi telah dikirim", nil)
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Email a single-use password reset link if the email is registered. Earlier reset links stop working. The response is the same whether or not the email is registered.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body body model.EmailRequest true "Account email"
+// @Success      202  {object}  utils.Response "Accepted"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      429  {object}  utils.Response "Too many requests from this IP (code: rate_limited); see the Retry-After header"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.EmailRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByEmail(r.Context(), req.Email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err == nil {
+		if err := h.sendPasswordResetEmail(r.Context(), user); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	// Respon sama untuk email terdaftar maupun tidak, agar endpoint ini tidak bisa dipakai menebak akun
+	utils.RespondSuccess(w, http.StatusAccepted, "Jika email terdaftar, tautan reset password telah dikirim", nil)
+}
+
+// ResetPassword godoc
+// @Summary      Reset a password
+// @Description  Set a new password with the single-use token from the reset email. Every active session of the account is revoked, so the user has to log in again.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body body model.ResetPasswordRequest true "Reset token and new password"
+// @Success      200  {object}  utils.Response "Password changed"
+// @Failure      400  {object}  utils.Response "Invalid, used or expired token"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      429  {object}  utils.Response "Too many requests from this IP (code: rate_limited); see the Retry-After header"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPasswordRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	// Hash dihitung lebih dulu agar token tidak terbuang jika hashing gagal
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal memproses password", err.Error())
+		return
+	}
+
+	token, err := h.UserTokens.ConsumeUserToken(r.Context(), utils.HashToken(req.Token), model.TokenPurposeResetPassword)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(w, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa", "invalid or expired token")
+		return
+	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	// Token hanya berlaku untuk alamat email tujuannya
+	user, err := h.UserRepo.GetUserByID(r.Context(), token.UserID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && user.Email != token.Email) {
+		utils.RespondError(w, http.StatusBadRequest, "Token tidak valid atau sudah kedaluwarsa", "token no longer matches account")
+		return
+	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	if err := h.UserRepo.UpdatePassword(r.Context(), user.ID, hashedPassword); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err := h.revokeAllSessions(r.Context(), user.ID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, http.StatusOK, "Password berhasil direset, silakan login kembali", nil)
+}
+
+// revokeAllSessions mencabut semua refresh token dan access token milik pengguna
+func (h *AuthHandler) revokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := h.TokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return h.Revocations.RevokeAllForUser(ctx, userID.String())
+}
+
+// sendVerificationEmail membuat token verifikasi baru dan mengirim tautannya ke email pengguna
+func (h *AuthHandler) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	ttl := h.Config.Account.EmailVerificationTTL
+	token, err := h.issueUserToken(ctx, user.ID, user.Email, model.TokenPurposeVerifyEmail, ttl)
+	if err != nil {
+		return err
+	}
+	link := h.Config.Account.AppURL + "/verify-email?token=" + url.QueryEscape(token)
+	h.sendMail(ctx, mailer.VerificationEmail(user.Email, user.FullName, link, ttl))
+	return nil
+}
+
+// sendPasswordResetEmail membuat token reset password baru dan mengirim tautannya ke email pengguna
+func (h *AuthHandler) sendPasswordResetEmail(ctx context.Context, user *model.User) error {
+	ttl := h.Config.Account.PasswordResetTTL
+	token, err := h.issueUserToken(ctx, user.ID, user.Email, model.TokenPurposeResetPassword, ttl)
+	if err != nil {
+		return err
+	}
+	link := h.Config.Account.AppURL + "/reset-password?token=" + url.QueryEscape(token)
+	h.sendMail(ctx, mailer.PasswordResetEmail(user.Email, user.FullName, link, ttl))
+	return nil
+}
+
+// sendEmailChangeEmail membuat token konfirmasi untuk alamat email baru dan mengirim tautannya ke alamat
+// tersebut. Email akun belum diganti sampai tautan dibuka.
+func (h *AuthHandler) sendEmailChangeEmail(ctx context.Context, user *model.User, newEmail string) error {
+	ttl := h.Config.Account.EmailVerificationTTL
+	token, err := h.issueUserToken(ctx, user.ID, newEmail, model.TokenPurposeChangeEmail, ttl)
+	if err != nil {
+		return err
+	}
+	link := h.Config.Account.AppURL + "/confirm-email-change?token=" + url.QueryEscape(token)
+	h.sendMail(ctx, mailer.EmailChangeEmail(newEmail, user.FullName, link, ttl))
+	return nil
+}
+
+// issueUserToken membuat token sekali pakai untuk alamat email tujuan, menyimpan hash-nya,
+// dan mengembalikan token aslinya untuk dikirim lewat email
+func (h *AuthHandler) issueUserToken(ctx context.Context, userID uuid.UUID, email, purpose string, ttl time.Duration) (string, error) {
+	plain, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	token := &model.UserToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(plain),
+		Email:     email,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := h.UserTokens.CreateUserToken(ctx, token); err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// sendMail mengirim email di background agar respon tidak menunggu server email, dan agar waktu
+// respon tidak membedakan email yang terdaftar dari yang tidak. Kegagalan hanya dicatat di log.
+func (h *AuthHandler) sendMail(ctx context.Context, msg mailer.Message) {
+	ctx = context.WithoutCancel(ctx) // Tetap membawa logger ber-request_id, tapi tidak ikut batal saat request selesai
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+		defer cancel()
+		if err := h.Mailer.Send(ctx, msg); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "Gagal mengirim email", "error", err, "subject", msg.Subject)
+		}
+	}()
+}
@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"fmt"
+	"gochi-boilerplate/internal/logging"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/utils"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// AdminHandler melayani endpoint pengelolaan pengguna oleh admin. Ia memakai ulang dependensi dan
+// helper AuthHandler (pencabutan sesi, token reset password, pengiriman email).
+type AdminHandler struct {
+	*AuthHandler
+}
+
+func NewAdminHandler(auth *AuthHandler) *AdminHandler {
+	return &AdminHandler{AuthHandler: auth}
+}
+
+// ListUsers godoc
+// @Summary      List users
+// @Description  Get a paginated, sortable and searchable list of users. Admin only.
+// @Description  Pagination works like GET /products: keyset (limit + cursor, the default) or offset (page + per_page, includes totals).
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        limit     query  int     false  "Keyset mode: number of items to return"  minimum(1)  maximum(100)  default(20)
+// @Param        cursor    query  string  false  "Keyset mode: next_cursor value from the previous page"
+// @Param        page      query  int     false  "Offset mode: page number, starting at 1; (page-1)*per_page must not exceed 2147483647"  minimum(1)
+// @Param        per_page  query  int     false  "Offset mode: number of items per page"  minimum(1)  maximum(100)  default(20)
+// @Param        sort      query  string  false  "Comma-separated sort fields (full_name, email, created_at, updated_at); prefix with - for descending"  default(-created_at)
+// @Param        q         query  string  false  "Case-insensitive substring match on full name or email"
+// @Param        role      query  string  false  "Only users with this role"  Enums(admin, editor, auditor, user)
+// @Param        status    query  string  false  "Only active or disabled accounts"  Enums(active, disabled)
+// @Success      200  {object}  utils.Response{data=[]model.User,pagination=utils.Pagination}
+// @Failure      400  {object}  utils.Response "Invalid query parameter"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Not an admin, or using an impersonation token"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /admin/users [get]
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parsePageParams(q, model.UserSortFields)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
+	filter, err := parseUserFilter(q)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
+
+	users, err := h.UserRepo.ListUsers(r.Context(), filter, page)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	var total int64
+	if page.OffsetMode() {
+		if total, err = h.UserRepo.CountUsers(r.Context(), filter); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	users, pagination, err := newPagination(page, users, total, func(u model.User) model.Cursor {
+		return model.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+	})
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat cursor paginasi", err.Error())
+		return
+	}
+	utils.RespondPaginated(w, http.StatusOK, "Berhasil mengambil daftar pengguna", users, pagination)
+}
+
+// parseUserFilter membaca filter daftar pengguna dari query string
+func parseUserFilter(q url.Values) (model.UserFilter, error) {
+	filter := model.UserFilter{Query: strings.TrimSpace(q.Get("q"))}
+
+	if role := q.Get("role"); role != "" {
+		if _, ok := model.RolePermissions[role]; !ok {
+			return filter, fmt.Errorf("role must be one of: %s", strings.Join(model.Roles(), ", "))
+		}
+		filter.Role = role
+	}
+
+	switch status := q.Get("status"); status {
+	case "":
+	case "active", "disabled":
+		disabled := status == "disabled"
+		filter.Disabled = &disabled
+	default:
+		return filter, fmt.Errorf("status must be one of: active, disabled")
+	}
+	return filter, nil
+}
+
+// GetUser godoc
+// @Summary      Get a user
+// @Description  Get a single user by ID. Admin only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"  format(uuid)
+// @Success      200  {object}  utils.Response{data=model.User}
+// @Failure      400  {object}  utils.Response "Invalid UUID"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Not an admin, or using an impersonation token"
+// @Failure      404  {object}  utils.Response "User not found (code: not_found)"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /admin/users/{id} [get]
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	_, target, ok := h.loadTarget(w, r, false)
+	if !ok {
+		return
+	}
+	utils.RespondSuccess(w, http.StatusOK, "Berhasil menemukan pengguna", target)
+}
+
+// UpdateRole godoc
+// @Summary      Change a user's role
+// @Description  Change the role of another user. The user's access tokens are revoked so the new role takes effect on the next token refresh. Admin only; admins cannot change their own role.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path  string                   true  "User ID"  format(uuid)
+// @Param        body  body  model.UpdateRoleRequest  true  "New role"
+// @Success      200  {object}  utils.Response{data=model.User}
+// @Failure      400  {object}  utils.Response "Invalid UUID, malformed JSON (code: invalid_body), or changing your own role"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Not an admin, or using an impersonation token"
+// @Failure      404  {object}  utils.Response "User not found (code: not_found)"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /admin/users/{id}/role [put]
+func (h *AdminHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdateRoleRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	adminID, target, ok := h.loadTarget(w, r, true)
+	if !ok {
+		return
+	}
+	if target.Role == req.Role {
+		utils.RespondSuccess(w, http.StatusOK, "Peran pengguna tidak berubah", target)
+		return
+	}
+
+	audit := &model.AuditEntry{
+		ActorID:      adminID,
+		Action:       model.AdminActionChangeRole,
+		TargetUserID: target.ID,
+		Details:      map[string]any{"from": target.Role, "to": req.Role},
+	}
+	if err := h.UserRepo.UpdateRole(r.Context(), target.ID, req.Role, audit); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	// Peran tersimpan di access token; mencabutnya memaksa klien refresh dan mendapat peran baru
+	if err := h.Revocations.RevokeAllForUser(r.Context(), target.ID.String()); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	target.Role = req.Role
+	utils.RespondSuccess(w, http.StatusOK, "Peran pengguna berhasil diubah", target)
+}
+
+// DisableUser godoc
+// @Summary      Disable a user
+// @Description  Disable another user's account. Every session of the account is revoked and login is refused until the account is enabled again. Admin only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"  format(uuid)
+// @Success      200  {object}  utils.Response{data=model.User}
+// @Failure      400  {object}  utils.Response "Invalid UUID or disabling your own account"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Not an admin, or using an impersonation token"
+// @Failure      404  {object}  utils.Response "User not found (code: not_found)"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /admin/users/{id}/disable [post]
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, true)
+}
+
+// EnableUser godoc
+// @Summary      Enable a user
+// @Description  Re-enable a disabled account and clear any login lockout. Admin only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"  format(uuid)
+// @Success      200  {object}  utils.Response{data=model.User}
+// @Failure      400  {object}  utils.Response "Invalid UUID or enabling your own account"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Not an admin, or using an impersonation token"
+// @Failure      404  {object}  utils.Response "User not found (code: not_found)"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /admin/users/{id}/enable [post]
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, false)
+}
+
+// setDisabled adalah implementasi bersama DisableUser dan EnableUser
+func (h *AdminHandler) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	adminID, target, ok := h.loadTarget(w, r, true)
+	if !ok {
+		return
+	}
+
+	action, message := model.AdminActionEnable, "Akun berhasil diaktifkan"
+	if disabled {
+		action, message = model.AdminActionDisable, "Akun berhasil dinonaktifkan"
+	}
+	audit := &model.AuditEntry{ActorID: adminID, Action: action, TargetUserID: target.ID}
+	if err := h.UserRepo.SetDisabled(r.Context(), target.ID, disabled, audit); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if disabled {
+		if err := h.revokeAllSessions(r.Context(), target.ID); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	target, err := h.UserRepo.GetUserByID(r.Context(), target.ID)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	utils.RespondSuccess(w, http.StatusOK, message, target)
+}
+
+// ForcePasswordReset godoc
+// @Summary      Force a password reset
+// @Description  Revoke every session of another user, refuse login until the password is reset, and email the user a reset link. Admin only.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"  format(uuid)
+// @Success      200  {object}  utils.Response "Reset link sent"
+// @Failure      400  {object}  utils.Response "Invalid UUID or targeting your own account"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Not an admin, or using an impersonation token"
+// @Failure      404  {object}  utils.Response "User not found (code: not_found)"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /admin/users/{id}/force-password-reset [post]
+func (h *AdminHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	adminID, target, ok := h.loadTarget(w, r, true)
+	if !ok {
+		return
+	}
+
+	audit := &model.AuditEntry{ActorID: adminID, Action: model.AdminActionForcePasswordReset, TargetUserID: target.ID}
+	if err := h.UserRepo.RequirePasswordReset(r.Context(), target.ID, audit); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err := h.revokeAllSessions(r.Context(), target.ID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err := h.sendPasswordResetEmail(r.Context(), target); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, http.StatusOK, "Sesi pengguna dicabut dan tautan reset password telah dikirim", nil)
+}
+
+// Impersonate godoc
+// @Summary      Impersonate a user
+// @Description  Issue a short-lived access token that acts as another user, for support and debugging. The token carries an impersonated_by claim, cannot be refreshed, cannot reach admin endpoints, and its issuance is recorded in the admin audit log. Other admins and disabled accounts cannot be impersonated.
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"  format(uuid)
+// @Success      200  {object}  utils.Response{data=model.ImpersonationResponse}
+// @Failure      400  {object}  utils.Response "Invalid UUID, targeting your own account, or the account is disabled"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Not an admin, using an impersonation token, or the target is an admin"
+// @Failure      404  {object}  utils.Response "User not found (code: not_found)"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /admin/users/{id}/impersonate [post]
+func (h *AdminHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	adminID, target, ok := h.loadTarget(w, r, true)
+	if !ok {
+		return
+	}
+	if target.Role == model.RoleAdmin {
+		utils.RespondError(w, http.StatusForbidden, "Admin lain tidak bisa di-impersonasi", "cannot impersonate an admin")
+		return
+	}
+	if target.IsDisabled() {
+		utils.RespondError(w, http.StatusBadRequest, "Akun yang dinonaktifkan tidak bisa di-impersonasi", "account disabled")
+		return
+	}
+
+	ttl := h.Config.JWT.ImpersonationTokenTTL
+	token, claims, err := h.Tokens.GenerateImpersonationToken(target.ID.String(), target.Role, adminID.String(), ttl)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat token", err.Error())
+		return
+	}
+
+	// Token hanya diberikan jika penerbitannya berhasil dicatat
+	audit := &model.AuditEntry{
+		ActorID:      adminID,
+		Action:       model.AdminActionImpersonate,
+		TargetUserID: target.ID,
+		Details:      map[string]any{"jti": claims.ID, "expires_at": claims.ExpiresAt.Time},
+	}
+	if err := h.UserRepo.RecordAudit(r.Context(), audit); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	logging.FromContext(r.Context()).InfoContext(r.Context(), "Token impersonasi diterbitkan",
+		"admin_id", adminID, "target_user_id", target.ID, "jti", claims.ID)
+
+	utils.RespondSuccess(w, http.StatusOK, "Token impersonasi berhasil dibuat", &model.ImpersonationResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresIn: int(ttl.Seconds()),
+		ExpiresAt: claims.ExpiresAt.Time,
+		User:      target,
+	})
+}
+
+// loadTarget membaca ID admin dari token dan pengguna target dari parameter path {id}.
+// Jika notSelf bernilai true, admin tidak boleh menargetkan akunnya sendiri.
+// Jika gagal, respon error sudah ditulis dan ok bernilai false.
+func (h *AdminHandler) loadTarget(w http.ResponseWriter, r *http.Request, notSelf bool) (uuid.UUID, *model.User, bool) {
+	adminID, ok := currentUserID(w, r)
+	if !ok {
+		return uuid.Nil, nil, false
+	}
+
+	targetID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Format UUID tidak valid", err.Error())
+		return uuid.Nil, nil, false
+	}
+	// Mencegah admin mengunci dirinya sendiri, misal menurunkan peran atau menonaktifkan akunnya
+	if notSelf && targetID == adminID {
+		utils.RespondError(w, http.StatusBadRequest, "Tindakan ini tidak bisa dilakukan pada akun sendiri", "cannot target own account")
+		return uuid.Nil, nil, false
+	}
+
+	target, err := h.UserRepo.GetUserByID(r.Context(), targetID)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return uuid.Nil, nil, false
+	}
+	return adminID, target, true
+}
@@ -0,0 +1,256 @@
+package handler_test
+
+import (
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/utils"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegisterVerifyAndLogin(t *testing.T) {
+	env := newTestEnv(t)
+	email := "budi@example.com"
+
+	env.do(http.MethodPost, "/auth/register", "", map[string]string{
+		"full_name": "Budi", "email": email, "password": testPassword,
+	}).expect(http.StatusCreated)
+	token := env.mail.nextToken(t, email)
+
+	// Login ditolak sampai email diverifikasi
+	env.do(http.MethodPost, "/auth/login", "", map[string]string{"email": email, "password": testPassword}).
+		expect(http.StatusForbidden)
+
+	env.do(http.MethodPost, "/auth/verify-email", "", map[string]string{"token": token}).expect(http.StatusOK)
+	env.do(http.MethodPost, "/auth/verify-email", "", map[string]string{"token": token}).expect(http.StatusBadRequest)
+
+	var me model.User
+	env.do(http.MethodGet, "/me", env.login(email).Token, nil).expect(http.StatusOK).decode(&me)
+	if me.Email != email || me.Role != model.RoleUser || me.EmailVerifiedAt == nil {
+		t.Fatalf("unexpected profile: %+v", me)
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	env := newTestEnv(t)
+	existing := env.createUser(model.RoleUser)
+
+	tests := []struct {
+		name   string
+		body   any
+		status int
+		code   string
+	}{
+		{"duplicate email", map[string]string{"full_name": "X", "email": existing.Email, "password": testPassword}, http.StatusConflict, utils.ErrCodeConflict},
+		{"validation", map[string]string{"full_name": " ", "email": "bukan-email", "password": "pendek"}, http.StatusUnprocessableEntity, utils.ErrCodeValidation},
+		// 40 karakter tetapi 80 byte, melebihi batas bcrypt
+		{"multibyte password over 72 bytes", map[string]string{"full_name": "X", "email": "x@example.com", "password": strings.Repeat("é", 40)}, http.StatusUnprocessableEntity, utils.ErrCodeValidation},
+		{"malformed json", `{"email":`, http.StatusBadRequest, utils.ErrCodeInvalidBody},
+		{"unknown field", map[string]string{"full_name": "X", "email": "x@example.com", "password": testPassword, "role": "admin"}, http.StatusBadRequest, utils.ErrCodeInvalidBody},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := env.withT(t)
+			env.do(http.MethodPost, "/auth/register", "", tt.body).expect(tt.status).expectCode(tt.code)
+		})
+	}
+
+	res := env.do(http.MethodPost, "/auth/register", "", map[string]string{"full_name": " ", "email": "bukan-email", "password": "pendek"})
+	for _, field := range []string{"full_name", "email", "password"} {
+		if res.body.Fields[field] == "" {
+			t.Errorf("missing validation error for %s: %v", field, res.body.Fields)
+		}
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(model.RoleUser)
+	wrong := map[string]string{"email": user.Email, "password": "salah-salah"}
+
+	env.do(http.MethodPost, "/auth/login", "", map[string]string{"email": "tidak-ada@example.com", "password": testPassword}).
+		expect(http.StatusUnauthorized)
+	env.do(http.MethodPost, "/auth/login", "", wrong).expect(http.StatusUnauthorized)
+	env.do(http.MethodPost, "/auth/login", "", wrong).expect(http.StatusUnauthorized)
+	res := env.do(http.MethodPost, "/auth/login", "", wrong).expect(http.StatusTooManyRequests).expectCode(utils.ErrCodeLocked)
+	if res.rec.Header().Get("Retry-After") == "" {
+		t.Error("missing Retry-After header")
+	}
+
+	// Password yang benar pun ditolak selama akun dikunci
+	env.do(http.MethodPost, "/auth/login", "", map[string]string{"email": user.Email, "password": testPassword}).
+		expect(http.StatusTooManyRequests).expectCode(utils.ErrCodeLocked)
+}
+
+func TestRefreshRotationAndReuse(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(model.RoleUser)
+	first := env.login(user.Email)
+
+	var second model.LoginResponse
+	env.do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": first.RefreshToken}).
+		expect(http.StatusOK).decode(&second)
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// Memakai token lama lagi mencabut seluruh family, termasuk token hasil rotasi
+	env.do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": first.RefreshToken}).
+		expect(http.StatusUnauthorized)
+	env.do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": second.RefreshToken}).
+		expect(http.StatusUnauthorized)
+	env.do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": "tidak-dikenal"}).
+		expect(http.StatusUnauthorized)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.token(model.RoleUser)
+
+	env.do(http.MethodGet, "/me", "", nil).expect(http.StatusUnauthorized)
+	env.do(http.MethodGet, "/me", "not-a-jwt", nil).expect(http.StatusUnauthorized)
+
+	env.do(http.MethodGet, "/me", token, nil).expect(http.StatusOK)
+	env.do(http.MethodPost, "/auth/logout", token, nil).expect(http.StatusOK)
+	env.do(http.MethodGet, "/me", token, nil).expect(http.StatusUnauthorized)
+}
+
+func TestChangePassword(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(model.RoleUser)
+	other := env.login(user.Email)
+	current := env.login(user.Email)
+
+	env.do(http.MethodPost, "/me/password", current.Token, map[string]string{
+		"current_password": "salah-salah", "new_password": "PasswordBaru1",
+	}).expect(http.StatusForbidden)
+	env.do(http.MethodPost, "/me/password", current.Token, map[string]string{
+		"current_password": testPassword, "new_password": testPassword,
+	}).expect(http.StatusUnprocessableEntity).expectCode(utils.ErrCodeValidation)
+	res := env.do(http.MethodPost, "/me/password", current.Token, map[string]string{
+		"current_password": testPassword, "new_password": strings.Repeat("密", 25),
+	}).expect(http.StatusUnprocessableEntity)
+	if msg := res.body.Fields["new_password"]; msg != "must be at most 72 bytes" {
+		t.Fatalf("new_password error = %q", msg)
+	}
+
+	var fresh model.LoginResponse
+	env.do(http.MethodPost, "/me/password", current.Token, map[string]string{
+		"current_password": testPassword, "new_password": "PasswordBaru1",
+	}).expect(http.StatusOK).decode(&fresh)
+
+	// Sesi lain dikeluarkan, termasuk access token yang terbit di detik yang sama, sedangkan sesi
+	// baru dari respon tetap berlaku
+	env.do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": other.RefreshToken}).
+		expect(http.StatusUnauthorized)
+	env.do(http.MethodGet, "/me", other.Token, nil).expect(http.StatusUnauthorized)
+	env.do(http.MethodGet, "/me", current.Token, nil).expect(http.StatusUnauthorized)
+	env.do(http.MethodGet, "/me", fresh.Token, nil).expect(http.StatusOK)
+	env.do(http.MethodPost, "/auth/login", "", map[string]string{"email": user.Email, "password": "PasswordBaru1"}).
+		expect(http.StatusOK)
+}
+
+func TestWrongCurrentPasswordLocksAccount(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.token(model.RoleUser)
+	wrong := map[string]string{"current_password": "salah-salah", "new_password": "PasswordBaru1"}
+
+	// Ambang penguncian di test adalah 3 kali gagal, sama seperti login
+	env.do(http.MethodPost, "/me/password", token, wrong).expect(http.StatusForbidden)
+	env.do(http.MethodDelete, "/me", token, map[string]string{"password": "salah-salah"}).expect(http.StatusForbidden)
+	env.do(http.MethodPost, "/me/password", token, wrong).
+		expect(http.StatusTooManyRequests).expectCode(utils.ErrCodeLocked)
+
+	// Selama dikunci, password yang benar pun ditolak, baik di sini maupun saat login
+	env.do(http.MethodPost, "/me/password", token, map[string]string{
+		"current_password": testPassword, "new_password": "PasswordBaru1",
+	}).expect(http.StatusTooManyRequests).expectCode(utils.ErrCodeLocked)
+	env.do(http.MethodPost, "/auth/login", "", map[string]string{"email": user.Email, "password": testPassword}).
+		expect(http.StatusTooManyRequests).expectCode(utils.ErrCodeLocked)
+}
+
+func TestChangeEmail(t *testing.T) {
+	env := newTestEnv(t)
+	user, token := env.token(model.RoleUser)
+	taken := env.createUser(model.RoleUser)
+	newEmail := "baru@example.com"
+
+	env.do(http.MethodPatch, "/me", token, map[string]string{"email": newEmail}).
+		expect(http.StatusUnprocessableEntity).expectCode(utils.ErrCodeValidation)
+	env.do(http.MethodPatch, "/me", token, map[string]string{"email": newEmail, "current_password": "salah-salah"}).
+		expect(http.StatusForbidden)
+	env.do(http.MethodPatch, "/me", token, map[string]string{"email": taken.Email, "current_password": testPassword}).
+		expect(http.StatusConflict).expectCode(utils.ErrCodeConflict)
+
+	// Email akun belum berubah sampai tautan di email baru dibuka
+	var me model.User
+	env.do(http.MethodPatch, "/me", token, map[string]string{"email": newEmail, "current_password": testPassword}).
+		expect(http.StatusOK).decode(&me)
+	if me.Email != user.Email {
+		t.Fatalf("email changed before confirmation: %q", me.Email)
+	}
+	confirm := env.mail.nextToken(t, newEmail)
+	env.do(http.MethodPost, "/auth/verify-email", "", map[string]string{"token": confirm}).expect(http.StatusBadRequest)
+
+	env.do(http.MethodPost, "/auth/confirm-email-change", "", map[string]string{"token": confirm}).
+		expect(http.StatusOK).decode(&me)
+	if me.Email != newEmail || me.EmailVerifiedAt == nil {
+		t.Fatalf("unexpected profile after confirmation: %+v", me)
+	}
+	if notice := env.mail.next(t, user.Email); !strings.Contains(notice.Body, newEmail) {
+		t.Fatalf("notice to old address does not mention the new one: %q", notice.Body)
+	}
+	env.do(http.MethodPost, "/auth/confirm-email-change", "", map[string]string{"token": confirm}).expect(http.StatusBadRequest)
+	env.login(newEmail)
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.token(model.RoleAdmin)
+	user, userToken := env.token(model.RoleUser)
+
+	env.do(http.MethodGet, "/admin/users", userToken, nil).expect(http.StatusForbidden)
+	env.do(http.MethodGet, "/admin/users?status=unknown", adminToken, nil).expect(http.StatusBadRequest)
+
+	var imp model.ImpersonationResponse
+	env.do(http.MethodPost, "/admin/users/"+user.ID.String()+"/impersonate", adminToken, nil).
+		expect(http.StatusOK).decode(&imp)
+	env.do(http.MethodGet, "/me", imp.Token, nil).expect(http.StatusOK)
+	env.do(http.MethodPost, "/me/password", imp.Token, map[string]string{
+		"current_password": testPassword, "new_password": "PasswordBaru1",
+	}).expect(http.StatusForbidden)
+	env.do(http.MethodPatch, "/me", imp.Token, map[string]string{
+		"email": "pengambilalihan@example.com", "current_password": testPassword,
+	}).expect(http.StatusForbidden)
+
+	env.do(http.MethodPost, "/admin/users/"+user.ID.String()+"/disable", adminToken, nil).expect(http.StatusOK)
+	env.do(http.MethodGet, "/me", userToken, nil).expect(http.StatusUnauthorized)
+	env.do(http.MethodPost, "/auth/login", "", map[string]string{"email": user.Email, "password": testPassword}).
+		expect(http.StatusForbidden)
+
+	if entries := env.db.AuditEntries(); len(entries) != 2 ||
+		entries[0].Action != model.AdminActionImpersonate || entries[1].Action != model.AdminActionDisable {
+		t.Fatalf("unexpected audit log: %+v", entries)
+	}
+}
+
+func TestUpdateRole(t *testing.T) {
+	env := newTestEnv(t)
+	_, adminToken := env.token(model.RoleAdmin)
+	user := env.createUser(model.RoleUser)
+	path := "/admin/users/" + user.ID.String() + "/role"
+
+	// Daftar peran yang valid diambil dari model.RolePermissions, bukan dari tag validasi
+	res := env.do(http.MethodPut, path, adminToken, map[string]string{"role": "superuser"}).
+		expect(http.StatusUnprocessableEntity).expectCode(utils.ErrCodeValidation)
+	if msg := res.body.Fields["role"]; msg != "must be one of: "+strings.Join(model.Roles(), ", ") {
+		t.Fatalf("role error = %q", msg)
+	}
+
+	var updated model.User
+	env.do(http.MethodPut, path, adminToken, map[string]string{"role": model.RoleEditor}).
+		expect(http.StatusOK).decode(&updated)
+	if updated.Role != model.RoleEditor {
+		t.Fatalf("role = %q, want %q", updated.Role, model.RoleEditor)
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"gochi-boilerplate/internal/model"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// productETag mengembalikan ETag kuat sebuah produk yang diturunkan dari version-nya, sehingga
+// berubah setiap kali produk diubah, dihapus, atau dipulihkan
+func productETag(p *model.Product) string {
+	return `"` + strconv.Itoa(p.Version) + `"`
+}
+
+// etagMatches memeriksa apakah salah satu ETag di header (dipisah koma, atau "*") cocok dengan etag.
+// If-Match memakai perbandingan kuat sehingga ETag lemah (W/"...") tidak pernah cocok, sedangkan
+// If-None-Match memakai perbandingan lemah yang mengabaikan awalan W/ (RFC 9110 bagian 8.8.3.2).
+func etagMatches(header []string, etag string, weak bool) bool {
+	for _, value := range header {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if weak {
+				tag = strings.TrimPrefix(tag, "W/")
+			}
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notModified menulis ETag dan, jika If-None-Match cocok, respon 304 tanpa body.
+// Mengembalikan true jika respon sudah selesai ditulis.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Values("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
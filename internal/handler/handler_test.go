@@ -0,0 +1,290 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gochi-boilerplate/internal/handler"
+	"gochi-boilerplate/internal/mailer"
+	"gochi-boilerplate/internal/middleware"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository/memory"
+	"gochi-boilerplate/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "Rahasia123"
+
+func TestMain(m *testing.M) {
+	utils.BcryptCost = bcrypt.MinCost
+	os.Exit(m.Run())
+}
+
+// testEnv adalah aplikasi lengkap di atas penyimpanan in-memory, dengan rute yang sama seperti cmd/server
+type testEnv struct {
+	t        *testing.T
+	db       *memory.DB
+	users    *memory.UserRepository
+	mail     *recordingMailer
+	products *handler.ProductHandler
+	router   http.Handler
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	cfg := &utils.Config{
+		Lockout: model.LockoutPolicy{Threshold: 3, Base: time.Minute, Max: time.Hour},
+		Account: utils.AccountConfig{
+			AppURL:                   "http://app.test",
+			RequireEmailVerification: true,
+			EmailVerificationTTL:     time.Hour,
+			PasswordResetTTL:         time.Hour,
+		},
+		JWT: utils.JWTConfig{
+			AccessTokenTTL:        15 * time.Minute,
+			RefreshTokenTTL:       24 * time.Hour,
+			ImpersonationTokenTTL: 5 * time.Minute,
+		},
+	}
+	tokens, err := utils.NewTokenManager("test-secret-with-at-least-32-characters", cfg.JWT.AccessTokenTTL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := memory.NewDB()
+	env := &testEnv{t: t, db: db, users: memory.NewUserRepository(db), mail: newRecordingMailer()}
+	revocations := memory.NewRevocationStore(db)
+	authHandler := handler.NewAuthHandler(cfg, env.users, memory.NewRefreshTokenRepository(db), revocations,
+		memory.NewUserTokenRepository(db), tokens, env.mail)
+	adminHandler := handler.NewAdminHandler(authHandler)
+	productHandler := handler.NewProductHandler(memory.NewProductRepository(db), cfg.RequireIfMatch)
+	env.products = productHandler
+	authMiddleware := middleware.AuthMiddleware(tokens, revocations)
+
+	r := chi.NewRouter()
+	r.Route("/auth", func(r chi.Router) {
+		r.Post("/register", authHandler.Register)
+		r.Post("/login", authHandler.Login)
+		r.Post("/refresh", authHandler.Refresh)
+		r.Post("/verify-email", authHandler.VerifyEmail)
+		r.Post("/confirm-email-change", authHandler.ConfirmEmailChange)
+		r.Post("/forgot-password", authHandler.ForgotPassword)
+		r.Post("/reset-password", authHandler.ResetPassword)
+		r.With(authMiddleware).Post("/logout", authHandler.Logout)
+		r.With(authMiddleware).Post("/logout-all", authHandler.LogoutAll)
+	})
+	r.Route("/me", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.Get("/", authHandler.GetMe)
+		r.Patch("/", authHandler.UpdateMe)
+		r.Delete("/", authHandler.DeleteMe)
+		r.Post("/password", authHandler.ChangePassword)
+	})
+	r.Route("/admin/users", func(r chi.Router) {
+		r.Use(authMiddleware, middleware.RequireAdmin)
+		r.Get("/", adminHandler.ListUsers)
+		r.Put("/{id}/role", adminHandler.UpdateRole)
+		r.Post("/{id}/disable", adminHandler.DisableUser)
+		r.Post("/{id}/impersonate", adminHandler.Impersonate)
+	})
+	r.Route("/products", func(r chi.Router) {
+		r.Use(authMiddleware)
+		r.With(middleware.RequirePermission(model.PermProductsCreate)).Post("/", productHandler.CreateProduct)
+		r.With(middleware.RequirePermission(model.PermProductsRead)).Get("/", productHandler.GetAllProducts)
+		r.With(middleware.RequirePermission(model.PermProductsRead)).Get("/search", productHandler.SearchProducts)
+		r.With(middleware.RequirePermission(model.PermProductsDelete)).Get("/trash", productHandler.ListTrash)
+		r.With(middleware.RequirePermission(model.PermProductsRead)).Get("/{id}", productHandler.GetProductByID)
+		r.With(middleware.RequirePermission(model.PermProductsUpdate)).Put("/{id}", productHandler.UpdateProduct)
+		r.With(middleware.RequirePermission(model.PermProductsUpdate)).Patch("/{id}", productHandler.PatchProduct)
+		r.With(middleware.RequirePermission(model.PermProductsDelete)).Delete("/{id}", productHandler.DeleteProduct)
+		r.With(middleware.RequirePermission(model.PermProductsDelete)).Post("/{id}/restore", productHandler.RestoreProduct)
+		r.With(middleware.RequirePermission(model.PermProductsPurge)).Delete("/{id}/permanent", productHandler.PurgeProduct)
+	})
+	env.router = r
+	return env
+}
+
+// withT mengembalikan salinan env yang melaporkan kegagalan ke t, untuk dipakai di dalam subtest
+func (e *testEnv) withT(t *testing.T) *testEnv {
+	sub := *e
+	sub.t = t
+	return &sub
+}
+
+// passwordHash di-cache karena bcrypt sengaja lambat
+var passwordHash = sync.OnceValue(func() string {
+	hash, err := utils.HashPassword(testPassword)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// createUser menyimpan pengguna terverifikasi dengan peran role dan password testPassword
+func (e *testEnv) createUser(role string) *model.User {
+	e.t.Helper()
+	now := time.Now()
+	id := uuid.New()
+	user := &model.User{
+		ID:              id,
+		FullName:        "Pengguna " + role,
+		Email:           role + "-" + id.String()[:8] + "@example.com",
+		Password:        passwordHash(),
+		Role:            role,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := e.users.CreateUser(context.Background(), user); err != nil {
+		e.t.Fatal(err)
+	}
+	return user
+}
+
+// login mengembalikan pasangan token untuk email dan testPassword
+func (e *testEnv) login(email string) model.LoginResponse {
+	e.t.Helper()
+	var resp model.LoginResponse
+	e.do(http.MethodPost, "/auth/login", "", map[string]string{"email": email, "password": testPassword}).
+		expect(http.StatusOK).decode(&resp)
+	return resp
+}
+
+// token membuat pengguna baru dengan peran role dan mengembalikan access token-nya
+func (e *testEnv) token(role string) (*model.User, string) {
+	e.t.Helper()
+	user := e.createUser(role)
+	return user, e.login(user.Email).Token
+}
+
+// do mengirim request ke router; body selain string di-encode sebagai JSON
+func (e *testEnv) do(method, path, token string, body any) *result {
+	e.t.Helper()
+	return e.doWithHeader(method, path, token, body, nil)
+}
+
+// doWithHeader seperti do, dengan header tambahan pada request
+func (e *testEnv) doWithHeader(method, path, token string, body any, header http.Header) *result {
+	e.t.Helper()
+	var buf bytes.Buffer
+	switch b := body.(type) {
+	case nil:
+	case string:
+		buf.WriteString(b)
+	default:
+		if err := json.NewEncoder(&buf).Encode(b); err != nil {
+			e.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+
+	res := &result{t: e.t, rec: rec}
+	if rec.Code == http.StatusNotModified {
+		return res
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res.body); err != nil {
+		e.t.Fatalf("%s %s: response is not JSON: %q", method, path, rec.Body.String())
+	}
+	return res
+}
+
+// result adalah respon yang sudah di-decode ke envelope utils.Response
+type result struct {
+	t    *testing.T
+	rec  *httptest.ResponseRecorder
+	body struct {
+		utils.Response
+		Data json.RawMessage `json:"data"`
+	}
+}
+
+func (r *result) expect(status int) *result {
+	r.t.Helper()
+	if r.rec.Code != status {
+		r.t.Fatalf("status = %d, want %d; body: %s", r.rec.Code, status, r.rec.Body.String())
+	}
+	return r
+}
+
+func (r *result) expectCode(code string) *result {
+	r.t.Helper()
+	if r.body.Code != code {
+		r.t.Fatalf("code = %q, want %q; body: %s", r.body.Code, code, r.rec.Body.String())
+	}
+	return r
+}
+
+func (r *result) decode(v any) {
+	r.t.Helper()
+	if err := json.Unmarshal(r.body.Data, v); err != nil {
+		r.t.Fatalf("decode data: %v; body: %s", err, r.rec.Body.String())
+	}
+}
+
+// recordingMailer menyimpan email yang dikirim agar test bisa mengambil tautan token
+type recordingMailer struct {
+	sent chan mailer.Message
+}
+
+func newRecordingMailer() *recordingMailer {
+	return &recordingMailer{sent: make(chan mailer.Message, 16)}
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+var tokenLink = regexp.MustCompile(`token=([^\s]+)`)
+
+// next menunggu email berikutnya (dikirim di background) dan memastikan tujuannya
+func (m *recordingMailer) next(t *testing.T, to string) mailer.Message {
+	t.Helper()
+	select {
+	case msg := <-m.sent:
+		if msg.To != to {
+			t.Fatalf("mail sent to %q, want %q", msg.To, to)
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no mail sent")
+		return mailer.Message{}
+	}
+}
+
+// nextToken menunggu email berikutnya dan mengambil token dari tautannya
+func (m *recordingMailer) nextToken(t *testing.T, to string) string {
+	t.Helper()
+	msg := m.next(t, to)
+	match := tokenLink.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("no token link in mail body: %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
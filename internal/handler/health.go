@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gochi-boilerplate/internal/migrate"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// healthCheckTimeout membatasi lama setiap pengecekan dependensi agar probe tidak ikut menggantung
+const healthCheckTimeout = 2 * time.Second
+
+// HealthStatus adalah hasil /healthz dan /readyz
+type HealthStatus struct {
+	Status string                 `json:"status" example:"ok"` // "ok", "unavailable", atau "shutting_down"
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult adalah hasil satu pengecekan dependensi
+type CheckResult struct {
+	Status    string  `json:"status" example:"ok"` // "ok" atau "fail"
+	LatencyMs float64 `json:"latency_ms" example:"1.25"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type HealthHandler struct {
+	DB           *pgxpool.Pool
+	Migrator     *migrate.Migrator
+	shuttingDown atomic.Bool
+}
+
+func NewHealthHandler(db *pgxpool.Pool, migrator *migrate.Migrator) *HealthHandler {
+	return &HealthHandler{DB: db, Migrator: migrator}
+}
+
+// SetShuttingDown membuat /readyz gagal sehingga load balancer berhenti mengirim trafik baru
+// selama graceful shutdown berlangsung. /healthz tidak terpengaruh.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness godoc
+// @Summary      Liveness probe
+// @Description  Reports that the process is running and able to serve HTTP. It does not check any dependency, so a database outage never causes a restart.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  HealthStatus
+// @Router       /healthz [get]
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthStatus{Status: "ok"})
+}
+
+// Readiness godoc
+// @Summary      Readiness probe
+// @Description  Pings the database and checks that every embedded migration has been applied, reporting the latency of each check. Returns 503 if any check fails or while a graceful shutdown is in progress.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  HealthStatus
+// @Failure      503  {object}  HealthStatus
+// @Router       /readyz [get]
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		writeHealth(w, http.StatusServiceUnavailable, HealthStatus{Status: "shutting_down"})
+		return
+	}
+
+	checks := map[string]func(ctx context.Context) error{
+		"database": h.DB.Ping,
+		"migrations": func(ctx context.Context) error {
+			pending, err := h.Migrator.Pending(ctx)
+			if err != nil {
+				return err
+			}
+			if pending > 0 {
+				return fmt.Errorf("%d pending migration(s)", pending)
+			}
+			return nil
+		},
+	}
+
+	result := HealthStatus{Status: "ok", Checks: make(map[string]CheckResult, len(checks))}
+	status := http.StatusOK
+	for name, check := range checks {
+		res := runCheck(r.Context(), check)
+		if res.Status != "ok" {
+			result.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+		result.Checks[name] = res
+	}
+	writeHealth(w, status, result)
+}
+
+// runCheck menjalankan satu pengecekan dengan batas waktu dan mengukur latensinya
+func runCheck(ctx context.Context, check func(ctx context.Context) error) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	res := CheckResult{Status: "ok", LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// writeHealth menulis hasil probe apa adanya (tanpa envelope utils.Response) agar mudah dibaca orchestrator
+func writeHealth(w http.ResponseWriter, statusCode int, status HealthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"encoding/json"
+	"gochi-boilerplate/internal/utils"
+	"net/http"
+)
+
+type JWKSHandler struct {
+	Tokens *utils.TokenManager
+}
+
+func NewJWKSHandler(tokens *utils.TokenManager) *JWKSHandler {
+	return &JWKSHandler{Tokens: tokens}
+}
+
+// GetJWKS godoc
+// @Summary      Get the JSON Web Key Set
+// @Description  Public keys used to verify access tokens issued by this service, identified by the "kid" token header. The body is a standard JWKS document (RFC 7517), not wrapped in the usual response envelope. Empty when the service signs with HS256.
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object}  utils.JWKS
+// @Router       /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	// Respon mengikuti format standar JWKS agar bisa langsung dipakai library JWT di layanan lain
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.Tokens.JWKS())
+}
@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/middleware"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"gochi-boilerplate/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetMe godoc
+// @Summary      Get the current user
+// @Description  Return the profile of the user identified by the access token.
+// @Tags         Profile
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Response{data=model.User}
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      404  {object}  utils.Response "Account no longer exists (code: not_found)"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /me [get]
+func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	utils.RespondSuccess(w, http.StatusOK, "Profil berhasil diambil", user)
+}
+
+// UpdateMe godoc
+// @Summary      Update the current user
+// @Description  Change full_name and/or email. Fields that are not sent stay unchanged. Changing the email requires current_password and is not allowed with an impersonation token. The account keeps its current email until the confirmation link sent to the new address is used (POST /auth/confirm-email-change).
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        profile body model.UpdateProfileRequest true "Fields to change"
+// @Success      200  {object}  utils.Response{data=model.User}
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Current password is wrong, or changing the email with an impersonation token"
+// @Failure      409  {object}  utils.Response "Email already used by another account (code: conflict)"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      429  {object}  utils.Response "Account temporarily locked after repeated wrong passwords (code: account_locked); see the Retry-After header"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /me [patch]
+func (h *AuthHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateProfileRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if req.Email != nil && isImpersonating(r) {
+		utils.RespondError(w, http.StatusForbidden, "Tindakan ini tidak diizinkan selama impersonasi", "not allowed with an impersonation token")
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	emailChanged := req.Email != nil && *req.Email != user.Email
+	if req.Email != nil && !h.checkCurrentPassword(w, r, user, req.CurrentPassword) {
+		return
+	}
+	if emailChanged {
+		// Konflik dicek lebih awal agar tautan konfirmasi tidak dikirim untuk email yang sudah dipakai
+		other, err := h.UserRepo.GetUserByEmail(r.Context(), *req.Email)
+		if err == nil && other.ID != user.ID {
+			utils.RespondErr(w, fmt.Errorf("email already registered: %w", repository.ErrConflict))
+			return
+		}
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	if req.FullName != nil {
+		user.FullName = *req.FullName
+		user.UpdatedAt = time.Now()
+		if err := h.UserRepo.UpdateProfile(r.Context(), user); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	// Email baru hanya disimpan di token konfirmasi; email akun diganti setelah tautannya dibuka
+	if emailChanged {
+		if err := h.sendEmailChangeEmail(r.Context(), user, *req.Email); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+		utils.RespondSuccess(w, http.StatusOK, "Profil berhasil diperbarui, buka tautan yang dikirim ke email baru untuk menyelesaikan penggantian email", user)
+		return
+	}
+	utils.RespondSuccess(w, http.StatusOK, "Profil berhasil diperbarui", user)
+}
+
+// ChangePassword godoc
+// @Summary      Change the current user's password
+// @Description  Requires the current password; wrong passwords count toward the login lockout. Every other session is logged out; the response carries a fresh token pair so this client stays signed in.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body model.ChangePasswordRequest true "Current and new password"
+// @Success      200  {object}  utils.Response{data=model.LoginResponse}
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Current password is wrong, or using an impersonation token"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      429  {object}  utils.Response "Account temporarily locked after repeated wrong passwords (code: account_locked); see the Retry-After header"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /me/password [post]
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	if isImpersonating(r) {
+		utils.RespondError(w, http.StatusForbidden, "Tindakan ini tidak diizinkan selama impersonasi", "not allowed with an impersonation token")
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if !h.checkCurrentPassword(w, r, user, req.CurrentPassword) {
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal memproses password", err.Error())
+		return
+	}
+
+	// Sesi dicabut lebih dulu dan password baru disimpan paling akhir: jika pencabutan gagal, password
+	// lama masih berlaku dan klien cukup mengulang, bukan password berganti sementara token curian tetap
+	// berlaku. Semua refresh token dicabut, lalu sesi baru diterbitkan untuk klien ini
+	if err := h.TokenRepo.RevokeAllForUser(r.Context(), user.ID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	refreshToken, stored, err := h.newRefreshToken(user.ID, uuid.New())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat refresh token", err.Error())
+		return
+	}
+	if err := h.TokenRepo.CreateRefreshToken(r.Context(), stored); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	resp, err := h.newLoginResponse(user, refreshToken)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat token", err.Error())
+		return
+	}
+	claims, err := h.Tokens.ValidateToken(resp.Token)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat token", err.Error())
+		return
+	}
+
+	// Semua access token yang terbit sampai saat ini dicabut, kecuali token baru di atas. Token baru
+	// dikecualikan lewat jti karena klaim iat hanya berpresisi detik dan tidak bisa membedakannya
+	// dari token lama yang terbit di detik yang sama.
+	if err := h.Revocations.RevokeAllForUserBefore(r.Context(), user.ID.String(), time.Now(), claims.ID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err := h.UserRepo.UpdatePassword(r.Context(), user.ID, hashedPassword); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	utils.RespondSuccess(w, http.StatusOK, "Password berhasil diganti, sesi lain telah dikeluarkan", resp)
+}
+
+// DeleteMe godoc
+// @Summary      Delete the current user's account
+// @Description  Permanently delete the account after confirming the password. All sessions are revoked. Products owned by the account are kept without an owner.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body model.DeleteAccountRequest true "Password confirmation"
+// @Success      200  {object}  utils.Response "Account deleted"
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Password is wrong, or using an impersonation token"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      429  {object}  utils.Response "Account temporarily locked after repeated wrong passwords (code: account_locked); see the Retry-After header"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /me [delete]
+func (h *AuthHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	if isImpersonating(r) {
+		utils.RespondError(w, http.StatusForbidden, "Tindakan ini tidak diizinkan selama impersonasi", "not allowed with an impersonation token")
+		return
+	}
+
+	var req model.DeleteAccountRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if !h.checkCurrentPassword(w, r, user, req.Password) {
+		return
+	}
+
+	// Access token dicabut lebih dulu; batas pencabutan tetap tersimpan setelah akun dihapus
+	if err := h.Revocations.RevokeAllForUser(r.Context(), user.ID.String()); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err := h.UserRepo.DeleteUser(r.Context(), user.ID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, http.StatusOK, "Akun berhasil dihapus", nil)
+}
+
+// checkCurrentPassword memastikan password yang dikirim adalah password pengguna saat ini. Seperti pada
+// login, akun yang sedang dikunci ditolak sebelum bcrypt dijalankan dan password salah dihitung ke
+// penguncian akun, sehingga access token curian tidak bisa dipakai menebak password tanpa batas.
+// Jika gagal, respon error sudah ditulis dan hasilnya false.
+func (h *AuthHandler) checkCurrentPassword(w http.ResponseWriter, r *http.Request, user *model.User, password string) bool {
+	now := time.Now()
+	if user.IsLocked(now) {
+		utils.RespondErr(w, &utils.RateLimitError{RetryAfter: user.LockedUntil.Sub(now), AccountLocked: true})
+		return false
+	}
+
+	if !utils.CheckPasswordHash(password, user.Password) {
+		lockedUntil, err := h.UserRepo.RecordLoginFailure(r.Context(), user.ID, h.Config.Lockout)
+		if err != nil {
+			utils.RespondErr(w, err)
+			return false
+		}
+		if lockedUntil != nil && lockedUntil.After(now) {
+			utils.RespondErr(w, &utils.RateLimitError{RetryAfter: lockedUntil.Sub(now), AccountLocked: true})
+			return false
+		}
+		utils.RespondError(w, http.StatusForbidden, "Password saat ini salah", "invalid current password")
+		return false
+	}
+
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := h.UserRepo.ResetLoginFailures(r.Context(), user.ID); err != nil {
+			utils.RespondErr(w, err)
+			return false
+		}
+	}
+	return true
+}
+
+// currentUserID mengambil ID pengguna dari claims yang disimpan AuthMiddleware.
+// Jika gagal, respon error sudah ditulis dan ok bernilai false.
+func currentUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(*utils.Claims)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal mendapatkan data pengguna dari token", "invalid context claims")
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal memproses ID pengguna", err.Error())
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// isImpersonating menandakan request memakai token impersonasi yang diterbitkan admin
+func isImpersonating(r *http.Request) bool {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(*utils.Claims)
+	return ok && claims.IsImpersonation()
+}
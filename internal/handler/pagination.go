@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/utils"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parsePageParams membaca parameter paginasi dan pengurutan dari query string.
+//
+// Mode offset dipakai jika page atau per_page dikirim; selain itu dipakai mode keyset
+// (limit + cursor) yang hanya mendukung pengurutan created_at karena cursor berisi (created_at, id).
+func parsePageParams(q url.Values, sortFields []string) (model.PageParams, error) {
+	var p model.PageParams
+
+	sort, err := parseSort(q.Get("sort"), sortFields)
+	if err != nil {
+		return p, err
+	}
+	p.Sort = sort
+
+	if q.Has("page") || q.Has("per_page") {
+		if q.Has("cursor") {
+			return p, fmt.Errorf("cursor cannot be combined with page/per_page")
+		}
+		p.Page, p.Limit, err = parseOffsetParams(q)
+		return p, err
+	}
+
+	if p.Limit, err = parseIntParam(q, "limit", model.DefaultPageLimit, 1, model.MaxPageLimit); err != nil {
+		return p, err
+	}
+	if len(p.Sort) == 0 {
+		p.Sort = []model.SortField{{Field: "created_at", Desc: true}}
+	}
+	if len(p.Sort) != 1 || p.Sort[0].Field != "created_at" {
+		return p, fmt.Errorf("cursor pagination only supports sort=created_at or sort=-created_at, use page/per_page for other sort orders")
+	}
+	if cursor := q.Get("cursor"); cursor != "" {
+		var after model.Cursor
+		if err := utils.DecodeCursor(cursor, &after); err != nil {
+			return p, fmt.Errorf("invalid cursor")
+		}
+		p.After = &after
+	}
+	return p, nil
+}
+
+// parseOffsetParams membaca page dan per_page. Batas atas page bergantung pada per_page agar offset
+// tidak melebihi model.MaxOffset; halaman di luar batas itu ditolak sebagai parameter tidak valid.
+func parseOffsetParams(q url.Values) (page, limit int, err error) {
+	if limit, err = parseIntParam(q, "per_page", model.DefaultPageLimit, 1, model.MaxPageLimit); err != nil {
+		return 0, 0, err
+	}
+	if page, err = parseIntParam(q, "page", 1, 1, model.MaxPage(limit)); err != nil {
+		return 0, 0, err
+	}
+	return page, limit, nil
+}
+
+// parseSort membaca parameter sort berformat "price,-created_at" (awalan "-" berarti menurun)
+func parseSort(raw string, allowed []string) ([]model.SortField, error) {
+	var sort []model.SortField
+	if raw == "" {
+		return sort, nil
+	}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		field := model.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !slices.Contains(allowed, field.Field) {
+			return nil, fmt.Errorf("cannot sort by %q, allowed fields: %s", field.Field, strings.Join(allowed, ", "))
+		}
+		sort = append(sort, field)
+	}
+	return sort, nil
+}
+
+// parseIntParam membaca parameter integer dengan nilai default dan batas min/max
+func parseIntParam(q url.Values, key string, fallback, min, max int) (int, error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be an integer between %d and %d", key, min, max)
+	}
+	return n, nil
+}
+
+// newPagination membuat metadata paginasi dari hasil query. Pada mode keyset, items berisi
+// Limit+1 baris; baris ekstra dibuang dan menjadi penanda masih ada halaman berikutnya.
+func newPagination[T any](page model.PageParams, items []T, total int64, cursorOf func(T) model.Cursor) ([]T, *utils.Pagination, error) {
+	if page.OffsetMode() {
+		totalPages := int((total + int64(page.Limit) - 1) / int64(page.Limit))
+		return items, &utils.Pagination{
+			Limit:      page.Limit,
+			HasMore:    page.Page < totalPages,
+			Page:       page.Page,
+			PerPage:    page.Limit,
+			Total:      &total,
+			TotalPages: totalPages,
+		}, nil
+	}
+
+	pagination := &utils.Pagination{Limit: page.Limit}
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+		cursor, err := utils.EncodeCursor(cursorOf(items[len(items)-1]))
+		if err != nil {
+			return nil, nil, err
+		}
+		pagination.HasMore = true
+		pagination.NextCursor = cursor
+	}
+	return items, pagination, nil
+}
@@ -1,12 +1,20 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/jsonpatch"
 	"gochi-boilerplate/internal/middleware"
 	"gochi-boilerplate/internal/model"
 	"gochi-boilerplate/internal/repository"
 	"gochi-boilerplate/internal/utils"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -14,11 +22,12 @@ import (
 )
 
 type ProductHandler struct {
-	Repo *repository.ProductRepository
+	Repo           repository.ProductStore
+	RequireIfMatch bool // Tolak PUT/DELETE tanpa header If-Match (428)
 }
 
-func NewProductHandler(repo *repository.ProductRepository) *ProductHandler {
-	return &ProductHandler{Repo: repo}
+func NewProductHandler(repo repository.ProductStore, requireIfMatch bool) *ProductHandler {
+	return &ProductHandler{Repo: repo, RequireIfMatch: requireIfMatch}
 }
 
 // CreateProduct godoc
@@ -30,9 +39,13 @@ func NewProductHandler(repo *repository.ProductRepository) *ProductHandler {
 // @Security     BearerAuth
 // @Param        product body model.CreateProductRequest true "Create Product"
 // @Success      201  {object}  utils.Response{data=model.Product}
-// @Failure      400  {object}  utils.Response "Bad Request"
+// @Header       201  {string}  ETag  "Current version of the product, for If-Match on later updates"
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
 // @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      413  {object}  utils.Response "Request body larger than 1 MiB (code: body_too_large)"
+// @Failure      422  {object}  utils.Response "Validation failed with per-field errors in fields (code: validation_failed) or database constraint violated (code: constraint_violation)"
 // @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
 // @Router       /products [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	// Ambil claims pengguna dari context yang sudah diisi oleh middleware
@@ -49,8 +62,8 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.CreateProductRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Request body tidak valid", err.Error())
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
 		return
 	}
 
@@ -64,44 +77,207 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.Repo.CreateProduct(r.Context(), product); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat produk", err.Error())
+		utils.RespondErr(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", productETag(product))
 	utils.RespondSuccess(w, http.StatusCreated, "Produk berhasil dibuat", product)
 }
 
 // GetAllProducts godoc
 // @Summary      Get all products
-// @Description  Get a list of all products. Requires authentication.
+// @Description  Get a paginated, sortable and filterable list of products. Requires authentication.
+// @Description  Two pagination modes are supported: keyset (limit + cursor, the default, ordered by created_at) and offset (page + per_page, includes totals). Keyset mode only supports sort=created_at or sort=-created_at.
 // @Tags         Products
 // @Produce      json
 // @Security     BearerAuth
-// @Success      200  {object}  utils.Response{data=[]model.Product}
+// @Param        limit          query  int     false  "Keyset mode: number of items to return"  minimum(1)  maximum(100)  default(20)
+// @Param        cursor         query  string  false  "Keyset mode: next_cursor value from the previous page"
+// @Param        page           query  int     false  "Offset mode: page number, starting at 1; (page-1)*per_page must not exceed 2147483647"  minimum(1)
+// @Param        per_page       query  int     false  "Offset mode: number of items per page"  minimum(1)  maximum(100)  default(20)
+// @Param        sort           query  string  false  "Comma-separated sort fields (name, price, created_at, updated_at); prefix with - for descending"  default(-created_at)  example(price,-created_at)
+// @Param        min_price      query  int     false  "Only products with price >= min_price"  minimum(0)
+// @Param        max_price      query  int     false  "Only products with price <= max_price"  minimum(0)
+// @Param        owner          query  string  false  "Only products owned by this user ID"  format(uuid)
+// @Param        q              query  string  false  "Case-insensitive substring match on the product name"
+// @Param        created_after  query  string  false  "Only products created after this time (RFC 3339)"  format(date-time)
+// @Param        include        query  string  false  "Comma-separated relations to embed"  Enums(owner)
+// @Success      200  {object}  utils.Response{data=[]model.Product,pagination=utils.Pagination}
+// @Failure      400  {object}  utils.Response "Invalid query parameter"
 // @Failure      401  {object}  utils.Response "Unauthorized"
 // @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
 // @Router       /products [get]
 func (h *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := h.Repo.GetAllProducts(r.Context())
+	q := r.URL.Query()
+	page, err := parsePageParams(q, model.ProductSortFields)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Gagal mengambil semua produk", err.Error())
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
 		return
 	}
-	utils.RespondSuccess(w, http.StatusOK, "Berhasil mengambil semua produk", products)
+	filter, err := parseProductFilter(q)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
+	include, err := parseProductInclude(q)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
+
+	products, err := h.Repo.GetAllProducts(r.Context(), filter, page, include)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	// Total hanya dihitung pada mode offset, mode keyset sengaja menghindari COUNT(*)
+	var total int64
+	if page.OffsetMode() {
+		if total, err = h.Repo.CountProducts(r.Context(), filter); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	products, pagination, err := newPagination(page, products, total, func(p model.Product) model.Cursor {
+		return model.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+	})
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat cursor paginasi", err.Error())
+		return
+	}
+	utils.RespondPaginated(w, http.StatusOK, "Berhasil mengambil semua produk", products, pagination)
+}
+
+// parseProductFilter membaca filter daftar produk dari query string
+func parseProductFilter(q url.Values) (model.ProductFilter, error) {
+	var filter model.ProductFilter
+	var err error
+
+	if filter.MinPrice, err = parsePriceParam(q, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePriceParam(q, "max_price"); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, fmt.Errorf("min_price must not be greater than max_price")
+	}
+
+	if raw := q.Get("owner"); raw != "" {
+		ownerID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, fmt.Errorf("owner must be a valid UUID")
+		}
+		filter.OwnerID = &ownerID
+	}
+
+	if raw := q.Get("created_after"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, fmt.Errorf("created_after must be an RFC 3339 timestamp, e.g. 2024-01-02T15:04:05Z")
+		}
+		filter.CreatedAfter = &t
+	}
+
+	filter.Query = strings.TrimSpace(q.Get("q"))
+	return filter, nil
+}
+
+// parseProductInclude membaca parameter include, misal "?include=owner"
+func parseProductInclude(q url.Values) (model.ProductInclude, error) {
+	var include model.ProductInclude
+	if raw := q.Get("include"); raw != "" {
+		for _, rel := range strings.Split(raw, ",") {
+			switch rel = strings.TrimSpace(rel); rel {
+			case "owner":
+				include.Owner = true
+			default:
+				return include, fmt.Errorf("cannot include %q, allowed relations: owner", rel)
+			}
+		}
+	}
+	return include, nil
+}
+
+// parsePriceParam membaca filter harga opsional; nil berarti parameter tidak dikirim
+func parsePriceParam(q url.Values, key string) (*int, error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer", key)
+	}
+	return &n, nil
+}
+
+// SearchProducts godoc
+// @Summary      Search products
+// @Description  Full-text search over product names, ordered by relevance. Every word is matched as a prefix, so "lap gam" finds "Laptop Gaming". The highlight field holds the HTML-escaped name with matching words wrapped in <mark> tags. Requires authentication.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q         query  string  true   "Search keywords"  example(laptop gaming)
+// @Param        page      query  int     false  "Page number, starting at 1; (page-1)*per_page must not exceed 2147483647"  minimum(1)  default(1)
+// @Param        per_page  query  int     false  "Number of results per page"  minimum(1)  maximum(100)  default(20)
+// @Success      200  {object}  utils.Response{data=[]model.ProductSearchResult,pagination=utils.Pagination}
+// @Failure      400  {object}  utils.Response "Missing or invalid query parameter"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /products/search [get]
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	text := strings.TrimSpace(q.Get("q"))
+	if text == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", "q is required")
+		return
+	}
+
+	// Hasil pencarian diurutkan berdasarkan relevansi, jadi hanya mode offset yang didukung
+	var page model.PageParams
+	var err error
+	if page.Page, page.Limit, err = parseOffsetParams(q); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
+
+	results, total, err := h.Repo.SearchProducts(r.Context(), text, page)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	results, pagination, err := newPagination(page, results, total, nil)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat metadata paginasi", err.Error())
+		return
+	}
+	utils.RespondPaginated(w, http.StatusOK, "Berhasil mencari produk", results, pagination)
 }
 
 // GetProductByID godoc
 // @Summary      Get a product by ID
-// @Description  Get a single product by its UUID. Requires authentication.
+// @Description  Get a single product by its UUID. Requires authentication. The response carries an ETag derived from the product version; send it back in If-None-Match to get 304 Not Modified while the product is unchanged, or in If-Match to update or delete it safely. Responses with include carry no ETag and are never 304, because embedded relations can change without a new product version.
 // @Tags         Products
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id   path      string  true  "Product ID" format(uuid)
+// @Param        id             path    string  true   "Product ID" format(uuid)
+// @Param        include        query   string  false  "Comma-separated relations to embed"  Enums(owner)
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200  {object}  utils.Response{data=model.Product}
-// @Failure      400  {object}  utils.Response "Invalid UUID format"
+// @Header       200  {string}  ETag  "Current version of the product; omitted when include is set"
+// @Success      304  "Product unchanged since the ETag in If-None-Match"
+// @Failure      400  {object}  utils.Response "Invalid UUID format or query parameter"
 // @Failure      401  {object}  utils.Response "Unauthorized"
-// @Failure      404  {object}  utils.Response "Product not found"
+// @Failure      404  {object}  utils.Response "Product not found (code: not_found)"
 // @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
 // @Router       /products/{id} [get]
 func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -110,32 +286,131 @@ func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request)
 		utils.RespondError(w, http.StatusBadRequest, "Format UUID tidak valid", err.Error())
 		return
 	}
+	include, err := parseProductInclude(r.URL.Query())
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
 
-	product, err := h.Repo.GetProductByID(r.Context(), id)
+	product, err := h.Repo.GetProductByID(r.Context(), id, include)
 	if err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Produk tidak ditemukan", err.Error())
+		utils.RespondErr(w, err)
+		return
+	}
+	// ETag hanya mewakili produk itu sendiri. Relasi yang ikut disematkan (misal nama pemilik) bisa
+	// berubah tanpa menaikkan version, jadi respon dengan include tidak diberi ETag dan tidak pernah 304.
+	if include == (model.ProductInclude{}) && notModified(w, r, productETag(product)) {
 		return
 	}
 	utils.RespondSuccess(w, http.StatusOK, "Berhasil menemukan produk", product)
 }
 
 // UpdateProduct godoc
-// @Summary      Update a product
-// @Description  Update an existing product's details. Only the product owner or an admin can perform this action.
+// @Summary      Replace a product
+// @Description  Replace all editable fields of a product: name and price are both required, so a field left out is a validation error rather than "unchanged". Use PATCH for partial updates. Requires the products:update permission; only the product owner or a role with products:update:any (admin, editor) can perform this action.
+// @Description  The update only succeeds if the product has not changed since it was read: a mismatching If-Match, or a concurrent change between the read and the write, returns 412. If-Match is mandatory when REQUIRE_IF_MATCH=true.
 // @Tags         Products
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id   path      string  true  "Product ID" format(uuid)
-// @Param        product body model.UpdateProductRequest true "Update Product"
+// @Param        id        path    string  true   "Product ID" format(uuid)
+// @Param        If-Match  header  string  false  "ETag from GET /products/{id}"
+// @Param        product body model.ReplaceProductRequest true "Replace Product"
 // @Success      200  {object}  utils.Response{data=model.Product}
-// @Failure      400  {object}  utils.Response "Bad Request"
+// @Header       200  {string}  ETag  "New version of the product"
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
 // @Failure      401  {object}  utils.Response "Unauthorized"
 // @Failure      403  {object}  utils.Response "Forbidden"
-// @Failure      404  {object}  utils.Response "Product not found"
+// @Failure      404  {object}  utils.Response "Product not found (code: not_found)"
+// @Failure      412  {object}  utils.Response "Product changed since it was read (code: precondition_failed)"
+// @Failure      413  {object}  utils.Response "Request body larger than 1 MiB (code: body_too_large)"
+// @Failure      422  {object}  utils.Response "Validation failed with per-field errors in fields (code: validation_failed) or database constraint violated (code: constraint_violation)"
+// @Failure      428  {object}  utils.Response "If-Match header missing while REQUIRE_IF_MATCH=true (code: precondition_required)"
 // @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
 // @Router       /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	h.modifyProduct(w, r, func(product *model.Product) error {
+		var req model.ReplaceProductRequest
+		if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+			return err
+		}
+		product.Name, product.Price = req.Name, *req.Price
+		return nil
+	})
+}
+
+// Media type yang diterima PATCH /products/{id}
+const (
+	mergePatchMediaType = "application/merge-patch+json"
+	jsonPatchMediaType  = "application/json-patch+json"
+)
+
+// PatchProduct godoc
+// @Summary      Partially update a product
+// @Description  Apply a patch to the product document {"name": ..., "price": ...}. The Content-Type selects the format: application/merge-patch+json (RFC 7396, e.g. {"price": 1200}) or application/json-patch+json (RFC 6902, an array of operations including test). The patched document must pass the same validation as PUT before it is saved. Permissions and If-Match handling are the same as PUT.
+// @Tags         Products
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path    string  true   "Product ID" format(uuid)
+// @Param        If-Match  header  string  false  "ETag from GET /products/{id}"
+// @Param        patch     body    []jsonpatch.Operation  true  "JSON Patch operations, or a merge patch object when Content-Type is application/merge-patch+json"
+// @Success      200  {object}  utils.Response{data=model.Product}
+// @Header       200  {string}  ETag  "New version of the product"
+// @Failure      400  {object}  utils.Response "Malformed JSON (code: invalid_body)"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Forbidden"
+// @Failure      404  {object}  utils.Response "Product not found (code: not_found)"
+// @Failure      409  {object}  utils.Response "A JSON Patch test operation did not match (code: patch_test_failed)"
+// @Failure      412  {object}  utils.Response "Product changed since it was read (code: precondition_failed)"
+// @Failure      413  {object}  utils.Response "Request body larger than 1 MiB (code: body_too_large)"
+// @Failure      415  {object}  utils.Response "Unsupported Content-Type; see the Accept-Patch response header"
+// @Failure      422  {object}  utils.Response "Patch cannot be applied (code: invalid_patch), patched product failed validation (code: validation_failed) or database constraint violated (code: constraint_violation)"
+// @Failure      428  {object}  utils.Response "If-Match header missing while REQUIRE_IF_MATCH=true (code: precondition_required)"
+// @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /products/{id} [patch]
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	var apply func(doc any, patch []byte) (any, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case mergePatchMediaType:
+		apply = applyMergePatch
+	case jsonPatchMediaType:
+		apply = applyJSONPatch
+	default:
+		w.Header().Set("Accept-Patch", mergePatchMediaType+", "+jsonPatchMediaType)
+		utils.RespondError(w, http.StatusUnsupportedMediaType, "Content-Type tidak didukung",
+			fmt.Sprintf("PATCH requires Content-Type %s or %s", mergePatchMediaType, jsonPatchMediaType))
+		return
+	}
+
+	h.modifyProduct(w, r, func(product *model.Product) error {
+		patch, err := utils.ReadBody(w, r)
+		if err != nil {
+			return err
+		}
+		doc, err := productDocument(product)
+		if err != nil {
+			return err
+		}
+		if doc, err = apply(doc, patch); err != nil {
+			return err
+		}
+		req, err := decodeProductDocument(doc)
+		if err != nil {
+			return err
+		}
+		product.Name, product.Price = req.Name, *req.Price
+		return nil
+	})
+}
+
+// modifyProduct menjalankan alur yang sama untuk PUT dan PATCH: membaca produk, memeriksa izin dan
+// If-Match, menerapkan change, lalu menyimpan dengan version yang dibaca di awal
+func (h *ProductHandler) modifyProduct(w http.ResponseWriter, r *http.Request, change func(product *model.Product) error) {
 	// Ambil claims pengguna dari context
 	claims, ok := r.Context().Value(middleware.UserClaimsKey).(*utils.Claims)
 	if !ok {
@@ -153,51 +428,104 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Ambil produk yang ada dari database
-	existingProduct, err := h.Repo.GetProductByID(r.Context(), productID)
+	existingProduct, err := h.Repo.GetProductByID(r.Context(), productID, model.ProductInclude{})
 	if err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Produk tidak ditemukan", err.Error())
+		utils.RespondErr(w, err)
 		return
 	}
 
-	// Otorisasi: Cek apakah pengguna adalah pemilik produk atau seorang admin
-	if (existingProduct.UserID == nil || *existingProduct.UserID != userIDFromToken) && claims.Role != "admin" {
+	// Otorisasi: pemilik produk boleh mengubah, selain itu butuh hak akses untuk mengubah produk siapa pun
+	if !canModify(existingProduct, userIDFromToken, claims.Role, model.PermProductsUpdateAny) {
 		utils.RespondError(w, http.StatusForbidden, "Akses ditolak", "Anda tidak memiliki izin untuk mengubah produk ini")
 		return
 	}
-
-	var req model.UpdateProductRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Request body tidak valid", err.Error())
+	if err := h.checkIfMatch(r, existingProduct); err != nil {
+		utils.RespondErr(w, err)
 		return
 	}
-	if req.Name != nil {
-		existingProduct.Name = *req.Name
-	}
-	if req.Price != nil {
-		existingProduct.Price = *req.Price
+
+	if err := change(existingProduct); err != nil {
+		utils.RespondErr(w, err)
+		return
 	}
 	existingProduct.UpdatedAt = time.Now()
+	// Version yang dibaca di atas ikut dikirim, sehingga perubahan dari request lain di antara
+	// pembacaan dan penulisan ini ditolak dengan ErrStale alih-alih tertimpa
 	if err := h.Repo.UpdateProduct(r.Context(), existingProduct); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Gagal mengupdate produk", err.Error())
+		utils.RespondErr(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", productETag(existingProduct))
 	utils.RespondSuccess(w, http.StatusOK, "Produk berhasil diupdate", existingProduct)
 }
 
+// productDocument mengubah produk menjadi dokumen JSON generik yang menjadi target patch, dengan
+// bentuk yang sama seperti body PUT
+func productDocument(p *model.Product) (any, error) {
+	data, err := json.Marshal(model.ReplaceProductRequest{Name: p.Name, Price: &p.Price})
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	err = json.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// decodeProductDocument membaca dokumen hasil patch kembali menjadi request yang tervalidasi.
+// Field yang tidak dikenal atau bertipe salah membuat patch ditolak.
+func decodeProductDocument(doc any) (model.ReplaceProductRequest, error) {
+	var req model.ReplaceProductRequest
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return req, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return req, &utils.PatchError{Err: fmt.Errorf("patched product is invalid: %w", err)}
+	}
+	return req, utils.Validate(&req)
+}
+
+func applyMergePatch(doc any, patch []byte) (any, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, &utils.BodyError{Err: err}
+	}
+	return jsonpatch.MergePatch(doc, p), nil
+}
+
+func applyJSONPatch(doc any, patch []byte) (any, error) {
+	var ops []jsonpatch.Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, &utils.BodyError{Err: err}
+	}
+	doc, err := jsonpatch.Apply(doc, ops)
+	if err != nil {
+		return nil, &utils.PatchError{TestFailed: errors.Is(err, jsonpatch.ErrTestFailed), Err: err}
+	}
+	return doc, nil
+}
+
 // DeleteProduct godoc
-// @Summary      Delete a product
-// @Description  Delete a product by its UUID. Only the product owner or an admin can perform this action.
+// @Summary      Move a product to the trash
+// @Description  Soft-delete a product by its UUID. The product disappears from every listing and lookup but can be restored from the trash until it is purged after TRASH_RETENTION_DAYS days. Requires the products:delete permission; only the product owner or a role with products:delete:any (admin) can perform this action.
+// @Description  Like PUT, the delete is rejected with 412 if the product changed since it was read or If-Match does not match.
 // @Tags         Products
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id   path      string  true  "Product ID" format(uuid)
-// @Success      200  {object}  utils.Response "Successfully deleted"
+// @Param        id        path    string  true   "Product ID" format(uuid)
+// @Param        If-Match  header  string  false  "ETag from GET /products/{id}"
+// @Success      200  {object}  utils.Response "Successfully moved to the trash"
 // @Failure      400  {object}  utils.Response "Invalid UUID format"
 // @Failure      401  {object}  utils.Response "Unauthorized"
 // @Failure      403  {object}  utils.Response "Forbidden"
-// @Failure      404  {object}  utils.Response "Product not found"
+// @Failure      404  {object}  utils.Response "Product not found (code: not_found)"
+// @Failure      412  {object}  utils.Response "Product changed since it was read (code: precondition_failed)"
+// @Failure      428  {object}  utils.Response "If-Match header missing while REQUIRE_IF_MATCH=true (code: precondition_required)"
 // @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
 // @Router       /products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	// Mirip dengan Update, kita cek kepemilikan
@@ -216,21 +544,217 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Cek kepemilikan sebelum menghapus
-	product, err := h.Repo.GetProductByID(r.Context(), productID)
+	product, err := h.Repo.GetProductByID(r.Context(), productID, model.ProductInclude{})
 	if err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Produk tidak ditemukan", err.Error())
+		utils.RespondErr(w, err)
 		return
 	}
 
-	if product.UserID == nil || *product.UserID != userIDFromToken && claims.Role != "admin" {
+	if !canModify(product, userIDFromToken, claims.Role, model.PermProductsDeleteAny) {
 		utils.RespondError(w, http.StatusForbidden, "Akses ditolak", "Anda tidak memiliki izin untuk menghapus produk ini")
 		return
 	}
+	if err := h.checkIfMatch(r, product); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	if err := h.Repo.DeleteProduct(r.Context(), productID, product.Version); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, http.StatusOK, "Produk berhasil dipindahkan ke trash", nil)
+}
+
+// ListTrash godoc
+// @Summary      List trashed products
+// @Description  Get a paginated list of soft-deleted products, newest first. Requires the products:delete permission. Without products:delete:any only the caller's own trash is listed, and asking for another owner's trash is forbidden.
+// @Description  Supports the same pagination, filter and include parameters as GET /products; sort additionally accepts deleted_at (offset mode only).
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Param        limit          query  int     false  "Keyset mode: number of items to return"  minimum(1)  maximum(100)  default(20)
+// @Param        cursor         query  string  false  "Keyset mode: next_cursor value from the previous page"
+// @Param        page           query  int     false  "Offset mode: page number, starting at 1; (page-1)*per_page must not exceed 2147483647"  minimum(1)
+// @Param        per_page       query  int     false  "Offset mode: number of items per page"  minimum(1)  maximum(100)  default(20)
+// @Param        sort           query  string  false  "Comma-separated sort fields (name, price, created_at, updated_at, deleted_at); prefix with - for descending"  default(-created_at)  example(-deleted_at)
+// @Param        min_price      query  int     false  "Only products with price >= min_price"  minimum(0)
+// @Param        max_price      query  int     false  "Only products with price <= max_price"  minimum(0)
+// @Param        owner          query  string  false  "Only products owned by this user ID"  format(uuid)
+// @Param        q              query  string  false  "Case-insensitive substring match on the product name"
+// @Param        created_after  query  string  false  "Only products created after this time (RFC 3339)"  format(date-time)
+// @Param        include        query  string  false  "Comma-separated relations to embed"  Enums(owner)
+// @Success      200  {object}  utils.Response{data=[]model.Product,pagination=utils.Pagination}
+// @Failure      400  {object}  utils.Response "Invalid query parameter"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Forbidden"
+// @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /products/trash [get]
+func (h *ProductHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(*utils.Claims)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal mendapatkan data pengguna dari token", "invalid context claims")
+		return
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal memproses ID pengguna", err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	page, err := parsePageParams(q, model.TrashSortFields)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
+	filter, err := parseProductFilter(q)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
+	include, err := parseProductInclude(q)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Parameter query tidak valid", err.Error())
+		return
+	}
+
+	// Tanpa hak akses untuk menghapus produk siapa pun, pengguna hanya boleh melihat trash miliknya
+	if !model.HasPermission(claims.Role, model.PermProductsDeleteAny) {
+		if filter.OwnerID != nil && *filter.OwnerID != userID {
+			utils.RespondError(w, http.StatusForbidden, "Akses ditolak", "Anda hanya dapat melihat trash milik Anda sendiri")
+			return
+		}
+		filter.OwnerID = &userID
+	}
+	filter.Trashed = true
+
+	products, err := h.Repo.GetAllProducts(r.Context(), filter, page, include)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	var total int64
+	if page.OffsetMode() {
+		if total, err = h.Repo.CountProducts(r.Context(), filter); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	products, pagination, err := newPagination(page, products, total, func(p model.Product) model.Cursor {
+		return model.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+	})
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat cursor paginasi", err.Error())
+		return
+	}
+	utils.RespondPaginated(w, http.StatusOK, "Berhasil mengambil produk di trash", products, pagination)
+}
+
+// RestoreProduct godoc
+// @Summary      Restore a product from the trash
+// @Description  Move a soft-deleted product back out of the trash. Requires the products:delete permission; only the product owner or a role with products:delete:any (admin) can perform this action.
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Product ID" format(uuid)
+// @Success      200  {object}  utils.Response{data=model.Product}
+// @Header       200  {string}  ETag  "New version of the product"
+// @Failure      400  {object}  utils.Response "Invalid UUID format"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Forbidden"
+// @Failure      404  {object}  utils.Response "Product not found in the trash (code: not_found)"
+// @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(*utils.Claims)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal mendapatkan data pengguna dari token", "invalid context claims")
+		return
+	}
+	userIDFromToken, _ := uuid.Parse(claims.UserID)
+
+	productID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Format UUID produk tidak valid", err.Error())
+		return
+	}
+
+	product, err := h.Repo.GetTrashedProductByID(r.Context(), productID)
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	// Yang boleh memulihkan sama dengan yang boleh menghapus
+	if !canModify(product, userIDFromToken, claims.Role, model.PermProductsDeleteAny) {
+		utils.RespondError(w, http.StatusForbidden, "Akses ditolak", "Anda tidak memiliki izin untuk memulihkan produk ini")
+		return
+	}
 
-	if err := h.Repo.DeleteProduct(r.Context(), productID); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Gagal menghapus produk", err.Error())
+	restored, err := h.Repo.RestoreProduct(r.Context(), productID)
+	if err != nil {
+		utils.RespondErr(w, err)
 		return
 	}
+	w.Header().Set("ETag", productETag(restored))
+	utils.RespondSuccess(w, http.StatusOK, "Produk berhasil dipulihkan", restored)
+}
 
-	utils.RespondSuccess(w, http.StatusOK, "Produk berhasil dihapus", nil)
-}
\ No newline at end of file
+// PurgeProduct godoc
+// @Summary      Permanently delete a product
+// @Description  Hard-delete a product, whether it is active or in the trash. This cannot be undone. Requires the products:purge permission (admin).
+// @Tags         Products
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Product ID" format(uuid)
+// @Success      200  {object}  utils.Response "Successfully deleted permanently"
+// @Failure      400  {object}  utils.Response "Invalid UUID format"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      403  {object}  utils.Response "Forbidden"
+// @Failure      404  {object}  utils.Response "Product not found (code: not_found)"
+// @Failure      500  {object}  utils.Response "Internal Server Error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
+// @Router       /products/{id}/permanent [delete]
+func (h *ProductHandler) PurgeProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Format UUID produk tidak valid", err.Error())
+		return
+	}
+
+	if err := h.Repo.PurgeProduct(r.Context(), productID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	utils.RespondSuccess(w, http.StatusOK, "Produk berhasil dihapus permanen", nil)
+}
+
+// checkIfMatch mencocokkan header If-Match dengan versi produk yang baru dibaca. Tanpa If-Match
+// request tetap diteruskan, kecuali RequireIfMatch aktif.
+func (h *ProductHandler) checkIfMatch(r *http.Request, product *model.Product) error {
+	ifMatch := r.Header.Values("If-Match")
+	if len(ifMatch) == 0 {
+		if h.RequireIfMatch {
+			return &utils.PreconditionError{Required: true}
+		}
+		return nil
+	}
+	if !etagMatches(ifMatch, productETag(product), false) {
+		return &utils.PreconditionError{}
+	}
+	return nil
+}
+
+// canModify memeriksa apakah pengguna adalah pemilik produk atau perannya memiliki hak akses anyPerm
+func canModify(product *model.Product, userID uuid.UUID, role string, anyPerm model.Permission) bool {
+	if product.UserID != nil && *product.UserID == userID {
+		return true
+	}
+	return model.HasPermission(role, anyPerm)
+}
@@ -0,0 +1,301 @@
+package handler_test
+
+import (
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/utils"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// createProduct membuat produk sebagai pemilik token dan mengembalikannya
+func (e *testEnv) createProduct(token, name string, price int) model.Product {
+	e.t.Helper()
+	var p model.Product
+	e.do(http.MethodPost, "/products", token, map[string]any{"name": name, "price": price}).
+		expect(http.StatusCreated).decode(&p)
+	return p
+}
+
+func TestProductOwnership(t *testing.T) {
+	env := newTestEnv(t)
+	owner, ownerToken := env.token(model.RoleUser)
+	_, otherToken := env.token(model.RoleUser)
+	_, editorToken := env.token(model.RoleEditor)
+	_, adminToken := env.token(model.RoleAdmin)
+
+	product := env.createProduct(ownerToken, "Laptop", 1000)
+	if product.UserID == nil || *product.UserID != owner.ID {
+		t.Fatalf("product owner = %v, want %v", product.UserID, owner.ID)
+	}
+	path := "/products/" + product.ID.String()
+	rename := func(name string) map[string]any { return map[string]any{"name": name, "price": 1000} }
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   any
+		status int
+	}{
+		{"other user cannot update", http.MethodPut, otherToken, rename("Dicuri"), http.StatusForbidden},
+		{"other user cannot delete", http.MethodDelete, otherToken, nil, http.StatusForbidden},
+		{"owner can update", http.MethodPut, ownerToken, rename("Laptop Baru"), http.StatusOK},
+		{"editor can update any product", http.MethodPut, editorToken, rename("Laptop Editor"), http.StatusOK},
+		{"editor cannot delete others' product", http.MethodDelete, editorToken, nil, http.StatusForbidden},
+		{"admin can delete any product", http.MethodDelete, adminToken, nil, http.StatusOK},
+		{"deleted product is gone", http.MethodGet, ownerToken, nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.withT(t).do(tt.method, path, tt.token, tt.body).expect(tt.status)
+		})
+	}
+}
+
+func TestProductPermissions(t *testing.T) {
+	env := newTestEnv(t)
+	_, auditorToken := env.token(model.RoleAuditor)
+	_, userToken := env.token(model.RoleUser)
+	product := env.createProduct(userToken, "Mouse", 50)
+
+	env.do(http.MethodPost, "/products", auditorToken, map[string]any{"name": "Keyboard", "price": 10}).
+		expect(http.StatusForbidden)
+	env.do(http.MethodGet, "/products/"+product.ID.String(), auditorToken, nil).expect(http.StatusOK)
+	env.do(http.MethodPut, "/products/"+product.ID.String(), auditorToken, map[string]string{"name": "X"}).
+		expect(http.StatusForbidden)
+}
+
+func TestProductErrors(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.token(model.RoleUser)
+	product := env.createProduct(token, "Monitor", 300)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		status int
+		code   string
+	}{
+		{"unknown id", http.MethodGet, "/products/00000000-0000-0000-0000-000000000000", nil, http.StatusNotFound, utils.ErrCodeNotFound},
+		{"invalid uuid", http.MethodGet, "/products/bukan-uuid", nil, http.StatusBadRequest, ""},
+		{"negative price", http.MethodPost, "/products", map[string]any{"name": "X", "price": -1}, http.StatusUnprocessableEntity, utils.ErrCodeValidation},
+		{"blank name", http.MethodPut, "/products/" + product.ID.String(), map[string]string{"name": "  "}, http.StatusUnprocessableEntity, utils.ErrCodeValidation},
+		{"malformed json", http.MethodPost, "/products", `{"name":`, http.StatusBadRequest, utils.ErrCodeInvalidBody},
+		{"invalid query", http.MethodGet, "/products?min_price=abc", nil, http.StatusBadRequest, ""},
+		{"page overflowing the offset", http.MethodGet, "/products?page=9223372036854775807&per_page=100", nil, http.StatusBadRequest, ""},
+		{"search page overflowing the offset", http.MethodGet, "/products/search?q=x&page=9223372036854775807&per_page=100", nil, http.StatusBadRequest, ""},
+		{"last allowed page", http.MethodGet, "/products?per_page=100&page=" + strconv.Itoa(model.MaxPage(100)), nil, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.withT(t).do(tt.method, tt.path, token, tt.body).expect(tt.status).expectCode(tt.code)
+		})
+	}
+}
+
+func TestProductListPagination(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.token(model.RoleUser)
+	for _, price := range []int{30, 10, 20} {
+		env.createProduct(token, "Produk", price)
+	}
+
+	// Mode offset: diurutkan berdasarkan harga dan menyertakan total
+	res := env.do(http.MethodGet, "/products?sort=price&per_page=2", token, nil).expect(http.StatusOK)
+	var products []model.Product
+	res.decode(&products)
+	if len(products) != 2 || products[0].Price != 10 || products[1].Price != 20 {
+		t.Fatalf("unexpected first page: %+v", products)
+	}
+	if p := res.body.Pagination; p == nil || p.Total == nil || *p.Total != 3 || !p.HasMore {
+		t.Fatalf("unexpected pagination: %+v", p)
+	}
+
+	// Mode keyset: ikuti next_cursor sampai habis
+	var seen int
+	path := "/products?limit=2"
+	for path != "" {
+		res := env.do(http.MethodGet, path, token, nil).expect(http.StatusOK)
+		var page []model.Product
+		res.decode(&page)
+		seen += len(page)
+		path = ""
+		if res.body.Pagination.HasMore {
+			path = "/products?limit=2&cursor=" + res.body.Pagination.NextCursor
+		}
+	}
+	if seen != 3 {
+		t.Fatalf("keyset pagination returned %d products, want 3", seen)
+	}
+}
+
+func TestProductTrash(t *testing.T) {
+	env := newTestEnv(t)
+	owner, ownerToken := env.token(model.RoleUser)
+	_, otherToken := env.token(model.RoleUser)
+	_, adminToken := env.token(model.RoleAdmin)
+	product := env.createProduct(ownerToken, "Laptop", 1000)
+	env.createProduct(otherToken, "Mouse", 50)
+	path := "/products/" + product.ID.String()
+
+	trash := func(token, query string) []model.Product {
+		t.Helper()
+		var products []model.Product
+		env.do(http.MethodGet, "/products/trash"+query, token, nil).expect(http.StatusOK).decode(&products)
+		return products
+	}
+
+	env.do(http.MethodDelete, path, ownerToken, nil).expect(http.StatusOK)
+	env.do(http.MethodGet, path, ownerToken, nil).expect(http.StatusNotFound)
+	env.do(http.MethodPut, path, ownerToken, map[string]string{"name": "X"}).expect(http.StatusNotFound)
+	env.do(http.MethodDelete, path, ownerToken, nil).expect(http.StatusNotFound)
+	var listed []model.Product
+	env.do(http.MethodGet, "/products", ownerToken, nil).expect(http.StatusOK).decode(&listed)
+	if len(listed) != 1 {
+		t.Fatalf("listing includes trashed product: %+v", listed)
+	}
+
+	// Trash pengguna biasa hanya berisi produk miliknya sendiri
+	if got := trash(ownerToken, ""); len(got) != 1 || got[0].ID != product.ID || got[0].DeletedAt == nil {
+		t.Fatalf("unexpected owner trash: %+v", got)
+	}
+	if got := trash(otherToken, ""); len(got) != 0 {
+		t.Fatalf("other user sees foreign trash: %+v", got)
+	}
+	env.do(http.MethodGet, "/products/trash?owner="+owner.ID.String(), otherToken, nil).expect(http.StatusForbidden)
+	if got := trash(adminToken, "?owner="+owner.ID.String()); len(got) != 1 {
+		t.Fatalf("admin cannot see owner's trash: %+v", got)
+	}
+
+	env.do(http.MethodPost, path+"/restore", otherToken, nil).expect(http.StatusForbidden)
+	var restored model.Product
+	env.do(http.MethodPost, path+"/restore", ownerToken, nil).expect(http.StatusOK).decode(&restored)
+	if restored.DeletedAt != nil || restored.Name != "Laptop" {
+		t.Fatalf("unexpected restored product: %+v", restored)
+	}
+	env.do(http.MethodPost, path+"/restore", ownerToken, nil).expect(http.StatusNotFound)
+	env.do(http.MethodGet, path, ownerToken, nil).expect(http.StatusOK)
+
+	// Hapus permanen hanya untuk admin dan tidak bisa dipulihkan
+	env.do(http.MethodDelete, path+"/permanent", ownerToken, nil).expect(http.StatusForbidden)
+	env.do(http.MethodDelete, path+"/permanent", adminToken, nil).expect(http.StatusOK)
+	env.do(http.MethodPost, path+"/restore", adminToken, nil).expect(http.StatusNotFound)
+	env.do(http.MethodDelete, path+"/permanent", adminToken, nil).expect(http.StatusNotFound)
+}
+
+func TestProductETag(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.token(model.RoleUser)
+	product := env.createProduct(token, "Laptop", 1000)
+	path := "/products/" + product.ID.String()
+	ifMatch := func(etag string) http.Header { return http.Header{"If-Match": {etag}} }
+
+	etag := env.do(http.MethodGet, path, token, nil).expect(http.StatusOK).rec.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("ETag = %q, want %q", etag, `"1"`)
+	}
+	env.doWithHeader(http.MethodGet, path, token, nil, http.Header{"If-None-Match": {`W/"1"`}}).expect(http.StatusNotModified)
+	env.doWithHeader(http.MethodGet, path, token, nil, http.Header{"If-None-Match": {`"0", "2"`}}).expect(http.StatusOK)
+
+	// Nama pemilik yang disematkan bisa berubah tanpa version baru, jadi respon dengan include tidak memakai ETag
+	res := env.doWithHeader(http.MethodGet, path+"?include=owner", token, nil, http.Header{"If-None-Match": {etag}}).expect(http.StatusOK)
+	if got := res.rec.Header().Get("ETag"); got != "" {
+		t.Fatalf("ETag with include = %q, want none", got)
+	}
+
+	// Perubahan dengan ETag yang benar menaikkan version; ETag lama kemudian ditolak
+	res = env.doWithHeader(http.MethodPut, path, token, map[string]any{"name": "Laptop", "price": 1200}, ifMatch(etag)).expect(http.StatusOK)
+	newETag := res.rec.Header().Get("ETag")
+	if newETag != `"2"` {
+		t.Fatalf("ETag after update = %q, want %q", newETag, `"2"`)
+	}
+	env.doWithHeader(http.MethodPut, path, token, map[string]any{"name": "Laptop", "price": 900}, ifMatch(etag)).
+		expect(http.StatusPreconditionFailed).expectCode(utils.ErrCodePreconditionFailed)
+	env.doWithHeader(http.MethodDelete, path, token, nil, ifMatch(etag)).
+		expect(http.StatusPreconditionFailed).expectCode(utils.ErrCodePreconditionFailed)
+	env.doWithHeader(http.MethodGet, path, token, nil, http.Header{"If-None-Match": {etag}}).expect(http.StatusOK)
+
+	// ETag lemah tidak pernah cocok dengan If-Match
+	env.doWithHeader(http.MethodPut, path, token, map[string]any{"name": "Laptop", "price": 900}, ifMatch("W/"+newETag)).
+		expect(http.StatusPreconditionFailed)
+
+	env.products.RequireIfMatch = true
+	env.do(http.MethodPut, path, token, map[string]any{"name": "Laptop", "price": 900}).
+		expect(http.StatusPreconditionRequired).expectCode(utils.ErrCodePreconditionRequired)
+	env.do(http.MethodDelete, path, token, nil).expect(http.StatusPreconditionRequired)
+	env.doWithHeader(http.MethodDelete, path, token, nil, ifMatch("*")).expect(http.StatusOK)
+}
+
+func TestProductReplaceAndPatch(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.token(model.RoleUser)
+	product := env.createProduct(token, "Laptop", 1000)
+	path := "/products/" + product.ID.String()
+	contentType := func(mediaType string) http.Header { return http.Header{"Content-Type": {mediaType}} }
+	mergePatch, jsonPatch := contentType("application/merge-patch+json"), contentType("application/json-patch+json")
+
+	check := func(res *result, name string, price int) {
+		t.Helper()
+		var got model.Product
+		res.expect(http.StatusOK).decode(&got)
+		if got.Name != name || got.Price != price {
+			t.Fatalf("got %q/%d, want %q/%d", got.Name, got.Price, name, price)
+		}
+	}
+
+	// PUT menggantikan seluruh field, sehingga field yang tidak dikirim adalah error validasi
+	env.do(http.MethodPut, path, token, map[string]any{"name": "Laptop Baru"}).
+		expect(http.StatusUnprocessableEntity).expectCode(utils.ErrCodeValidation)
+	check(env.do(http.MethodPut, path, token, map[string]any{"name": "Laptop Baru", "price": 0}), "Laptop Baru", 0)
+
+	check(env.doWithHeader(http.MethodPatch, path, token, `{"price": 1500}`, mergePatch), "Laptop Baru", 1500)
+	check(env.doWithHeader(http.MethodPatch, path, token,
+		`[{"op":"test","path":"/price","value":1500},{"op":"replace","path":"/name","value":"Laptop Pro"}]`, jsonPatch), "Laptop Pro", 1500)
+
+	tests := []struct {
+		name   string
+		body   string
+		header http.Header
+		status int
+		code   string
+	}{
+		{"unsupported content type", `{"price": 1}`, contentType("application/json"), http.StatusUnsupportedMediaType, ""},
+		{"malformed merge patch", `{"price":`, mergePatch, http.StatusBadRequest, utils.ErrCodeInvalidBody},
+		{"merge patch removes required field", `{"name": null}`, mergePatch, http.StatusUnprocessableEntity, utils.ErrCodeValidation},
+		{"merge patch fails validation", `{"price": -1}`, mergePatch, http.StatusUnprocessableEntity, utils.ErrCodeValidation},
+		{"merge patch adds unknown field", `{"color": "red"}`, mergePatch, http.StatusUnprocessableEntity, utils.ErrCodeInvalidPatch},
+		{"merge patch wrong type", `{"price": "mahal"}`, mergePatch, http.StatusUnprocessableEntity, utils.ErrCodeInvalidPatch},
+		{"json patch is not an array", `{"op":"remove","path":"/name"}`, jsonPatch, http.StatusBadRequest, utils.ErrCodeInvalidBody},
+		{"json patch test fails", `[{"op":"test","path":"/name","value":"Mouse"},{"op":"replace","path":"/price","value":1}]`, jsonPatch, http.StatusConflict, utils.ErrCodePatchTestFailed},
+		{"json patch missing path", `[{"op":"replace","path":"/owner","value":"x"}]`, jsonPatch, http.StatusUnprocessableEntity, utils.ErrCodeInvalidPatch},
+		{"json patch fails validation", `[{"op":"replace","path":"/name","value":" "}]`, jsonPatch, http.StatusUnprocessableEntity, utils.ErrCodeValidation},
+		{"empty body", ``, jsonPatch, http.StatusBadRequest, utils.ErrCodeInvalidBody},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := env.withT(t).doWithHeader(http.MethodPatch, path, token, tt.body, tt.header).expect(tt.status).expectCode(tt.code)
+			if tt.status == http.StatusUnsupportedMediaType && res.rec.Header().Get("Accept-Patch") == "" {
+				t.Fatal("Accept-Patch header missing")
+			}
+		})
+	}
+
+	// Patch yang gagal tidak menyimpan apa pun
+	check(env.do(http.MethodGet, path, token, nil), "Laptop Pro", 1500)
+}
+
+func TestSearchProductsEscapesHighlight(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.token(model.RoleUser)
+	env.createProduct(token, `<script>alert("x")</script> Keyboard`, 10)
+
+	var results []model.ProductSearchResult
+	env.do(http.MethodGet, "/products/search?q=keyb", token, nil).expect(http.StatusOK).decode(&results)
+	want := `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <mark>Keyboard</mark>`
+	if len(results) != 1 || results[0].Highlight != want {
+		t.Fatalf("highlight = %+v, want %q", results, want)
+	}
+}
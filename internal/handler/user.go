@@ -1,7 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"gochi-boilerplate/internal/logging"
+	"gochi-boilerplate/internal/mailer"
+	"gochi-boilerplate/internal/metrics"
+	"gochi-boilerplate/internal/middleware"
 	"gochi-boilerplate/internal/model"
 	"gochi-boilerplate/internal/repository"
 	"gochi-boilerplate/internal/utils"
@@ -12,28 +16,38 @@ import (
 )
 
 type AuthHandler struct {
-	UserRepo *repository.UserRepository
+	Config      *utils.Config
+	UserRepo    repository.UserStore
+	TokenRepo   repository.RefreshTokenStore
+	Revocations repository.TokenRevoker
+	UserTokens  repository.UserTokenStore
+	Tokens      *utils.TokenManager
+	Mailer      mailer.Mailer
 }
 
-func NewAuthHandler(userRepo *repository.UserRepository) *AuthHandler {
-	return &AuthHandler{UserRepo: userRepo}
+func NewAuthHandler(cfg *utils.Config, userRepo repository.UserStore, tokenRepo repository.RefreshTokenStore, revocations repository.TokenRevoker, userTokens repository.UserTokenStore, tokens *utils.TokenManager, mail mailer.Mailer) *AuthHandler {
+	return &AuthHandler{Config: cfg, UserRepo: userRepo, TokenRepo: tokenRepo, Revocations: revocations, UserTokens: userTokens, Tokens: tokens, Mailer: mail}
 }
 
 // Register godoc
 // @Summary      Register a new user
-// @Description  Create a new user account with full name, email, and password.
+// @Description  Create a new user account with full name, email, and password. A verification link is sent to the email; login is refused until the email is verified when REQUIRE_EMAIL_VERIFICATION is on.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        user body model.RegisterRequest true "User Registration Details"
 // @Success      201  {object}  utils.Response "Successfully registered"
-// @Failure      400  {object}  utils.Response "Invalid request body"
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
+// @Failure      413  {object}  utils.Response "Request body larger than 1 MiB (code: body_too_large)"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      409  {object}  utils.Response "Email already registered (code: conflict)"
 // @Failure      500  {object}  utils.Response "Internal server error"
+// @Failure      503  {object}  utils.Response "Database unavailable (code: service_unavailable)"
 // @Router       /auth/register [post]
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req model.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Request body tidak valid", err.Error())
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
 		return
 	}
 
@@ -48,55 +62,332 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		FullName:  req.FullName,
 		Email:     req.Email,
 		Password:  hashedPassword,
-		Role:      "user",
+		Role:      model.RoleUser,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	if err := h.UserRepo.CreateUser(r.Context(), user); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat pengguna", err.Error())
+		utils.RespondErr(w, err)
 		return
 	}
 
-	utils.RespondSuccess(w, http.StatusCreated, "Registrasi berhasil", nil)
+	// Akun sudah tersimpan; jika email verifikasi gagal dibuat, pengguna bisa meminta kirim ulang
+	if err := h.sendVerificationEmail(r.Context(), user); err != nil {
+		logging.FromContext(r.Context()).ErrorContext(r.Context(), "Gagal membuat token verifikasi email", "error", err)
+	}
+
+	utils.RespondSuccess(w, http.StatusCreated, "Registrasi berhasil, silakan cek email untuk verifikasi", nil)
 }
 
 // Login godoc
 // @Summary      Login a user
-// @Description  Authenticate a user with email and password to get a JWT.
+// @Description  Authenticate a user with email and password to get a short-lived JWT access token and an opaque refresh token. After repeated wrong passwords the account is locked with exponential backoff.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        credentials body model.LoginRequest true "User Login Credentials"
 // @Success      200  {object}  utils.Response{data=model.LoginResponse} "Successfully logged in with token"
-// @Failure      400  {object}  utils.Response "Invalid request body"
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
+// @Failure      413  {object}  utils.Response "Request body larger than 1 MiB (code: body_too_large)"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
 // @Failure      401  {object}  utils.Response "Unauthorized - Invalid credentials"
+// @Failure      403  {object}  utils.Response "Email not verified yet, account disabled by an admin, or a password reset is required"
+// @Failure      429  {object}  utils.Response "Too many attempts from this IP (code: rate_limited) or account temporarily locked after repeated failures (code: account_locked); see the Retry-After header"
 // @Failure      500  {object}  utils.Response "Internal server error"
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req model.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Request body tidak valid", err.Error())
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
 		return
 	}
 
 	user, err := h.UserRepo.GetUserByEmail(r.Context(), req.Email)
-	if err != nil {
+	if errors.Is(err, repository.ErrNotFound) {
+		metrics.ObserveLogin(false)
 		utils.RespondError(w, http.StatusUnauthorized, "Email atau password salah", "user not found")
 		return
 	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	// Akun yang sedang dikunci ditolak sebelum bcrypt dijalankan, sehingga brute force tidak membakar CPU
+	now := time.Now()
+	if user.IsLocked(now) {
+		metrics.ObserveLogin(false)
+		utils.RespondErr(w, &utils.RateLimitError{RetryAfter: user.LockedUntil.Sub(now), AccountLocked: true})
+		return
+	}
 
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		metrics.ObserveLogin(false)
+		lockedUntil, err := h.UserRepo.RecordLoginFailure(r.Context(), user.ID, h.Config.Lockout)
+		if err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+		if lockedUntil != nil && lockedUntil.After(now) {
+			utils.RespondErr(w, &utils.RateLimitError{RetryAfter: lockedUntil.Sub(now), AccountLocked: true})
+			return
+		}
 		utils.RespondError(w, http.StatusUnauthorized, "Email atau password salah", "invalid password")
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID.String(), user.Role)
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := h.UserRepo.ResetLoginFailures(r.Context(), user.ID); err != nil {
+			utils.RespondErr(w, err)
+			return
+		}
+	}
+
+	// Dicek setelah password benar agar status akun tidak bocor ke orang yang tidak tahu password
+	if user.IsDisabled() {
+		metrics.ObserveLogin(false)
+		utils.RespondError(w, http.StatusForbidden, "Akun dinonaktifkan, hubungi administrator", "account disabled")
+		return
+	}
+	if user.PasswordResetRequired {
+		metrics.ObserveLogin(false)
+		utils.RespondError(w, http.StatusForbidden, "Password harus direset, silakan cek email Anda untuk tautan reset password", "password reset required")
+		return
+	}
+	if h.Config.Account.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		metrics.ObserveLogin(false)
+		utils.RespondError(w, http.StatusForbidden, "Email belum diverifikasi, silakan cek email Anda", "email not verified")
+		return
+	}
+
+	refreshToken, stored, err := h.newRefreshToken(user.ID, uuid.New())
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat refresh token", err.Error())
+		return
+	}
+	if err := h.TokenRepo.CreateRefreshToken(r.Context(), stored); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	resp, err := h.newLoginResponse(user, refreshToken)
 	if err != nil {
 		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat token", err.Error())
 		return
 	}
-	
-	resp := model.LoginResponse{Token: token}
+	metrics.ObserveLogin(true)
 	utils.RespondSuccess(w, http.StatusOK, "Login berhasil", resp)
-}
\ No newline at end of file
+}
+
+// Refresh godoc
+// @Summary      Refresh an access token
+// @Description  Exchange a refresh token for a new access token and a new refresh token. Each refresh token can only be used once; presenting a token that was already rotated revokes every token issued from the same login.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body body model.RefreshTokenRequest true "Refresh Token"
+// @Success      200  {object}  utils.Response{data=model.LoginResponse} "Successfully refreshed"
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
+// @Failure      413  {object}  utils.Response "Request body larger than 1 MiB (code: body_too_large)"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      401  {object}  utils.Response "Unauthorized - Invalid, expired or reused refresh token"
+// @Failure      429  {object}  utils.Response "Too many requests from this IP (code: rate_limited); see the Retry-After header"
+// @Failure      500  {object}  utils.Response "Internal server error"
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshTokenRequest
+	if err := utils.DecodeAndValidate(w, r, &req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	current, err := h.TokenRepo.GetRefreshTokenByHash(r.Context(), utils.HashToken(req.RefreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(w, http.StatusUnauthorized, "Refresh token tidak valid", "refresh token not found")
+		return
+	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	// Deteksi reuse: token yang sudah dirotasi/dicabut dipakai lagi, kemungkinan besar token dicuri.
+	// Cabut seluruh family agar pencuri maupun pemilik asli harus login ulang.
+	if current.RevokedAt != nil {
+		h.revokeFamily(w, r, current.FamilyID)
+		return
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		utils.RespondError(w, http.StatusUnauthorized, "Refresh token sudah kedaluwarsa", "refresh token expired")
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(r.Context(), current.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(w, http.StatusUnauthorized, "Refresh token tidak valid", "user not found")
+		return
+	}
+	if err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if user.IsDisabled() || user.PasswordResetRequired {
+		utils.RespondError(w, http.StatusUnauthorized, "Refresh token tidak valid", "account disabled or password reset required")
+		return
+	}
+
+	refreshToken, next, err := h.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat refresh token", err.Error())
+		return
+	}
+	if err := h.TokenRepo.RotateRefreshToken(r.Context(), current.ID, next); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			h.revokeFamily(w, r, current.FamilyID)
+			return
+		}
+		utils.RespondErr(w, err)
+		return
+	}
+
+	resp, err := h.newLoginResponse(user, refreshToken)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal membuat token", err.Error())
+		return
+	}
+	utils.RespondSuccess(w, http.StatusOK, "Token berhasil diperbarui", resp)
+}
+
+// Logout godoc
+// @Summary      Logout the current session
+// @Description  Revoke the access token used for this request. If a refresh token is sent in the body, its whole token family is revoked as well.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body model.LogoutRequest false "Refresh token to revoke"
+// @Success      200  {object}  utils.Response "Successfully logged out"
+// @Failure      400  {object}  utils.Response "Malformed JSON or unknown field (code: invalid_body)"
+// @Failure      413  {object}  utils.Response "Request body larger than 1 MiB (code: body_too_large)"
+// @Failure      422  {object}  utils.Response "Validation failed, per-field errors in fields (code: validation_failed)"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      500  {object}  utils.Response "Internal server error"
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(*utils.Claims)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal mendapatkan data pengguna dari token", "invalid context claims")
+		return
+	}
+
+	// Body bersifat opsional, jadi body kosong tidak dianggap error
+	var req model.LogoutRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil && !errors.Is(err, utils.ErrEmptyBody) {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err := utils.Validate(&req); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	if req.RefreshToken != "" {
+		stored, err := h.TokenRepo.GetRefreshTokenByHash(r.Context(), utils.HashToken(req.RefreshToken))
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			utils.RespondErr(w, err)
+			return
+		}
+		// Refresh token milik pengguna lain diabaikan agar logout tidak bisa dipakai untuk mengganggu sesi orang lain
+		if err == nil && stored.UserID.String() == claims.UserID {
+			if err := h.TokenRepo.RevokeFamily(r.Context(), stored.FamilyID); err != nil {
+				utils.RespondErr(w, err)
+				return
+			}
+		}
+	}
+
+	if err := h.Revocations.RevokeToken(r.Context(), claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, http.StatusOK, "Logout berhasil", nil)
+}
+
+// LogoutAll godoc
+// @Summary      Logout from every session
+// @Description  Revoke every access token and refresh token issued to the current user, on all devices.
+// @Tags         Authentication
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  utils.Response "Successfully logged out from all sessions"
+// @Failure      401  {object}  utils.Response "Unauthorized"
+// @Failure      500  {object}  utils.Response "Internal server error"
+// @Router       /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserClaimsKey).(*utils.Claims)
+	if !ok {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal mendapatkan data pengguna dari token", "invalid context claims")
+		return
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Gagal memproses ID pengguna", err.Error())
+		return
+	}
+
+	if err := h.TokenRepo.RevokeAllForUser(r.Context(), userID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	if err := h.Revocations.RevokeAllForUser(r.Context(), claims.UserID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+
+	utils.RespondSuccess(w, http.StatusOK, "Berhasil logout dari semua sesi", nil)
+}
+
+// revokeFamily mencabut seluruh family refresh token dan merespon 401 karena terdeteksi reuse
+func (h *AuthHandler) revokeFamily(w http.ResponseWriter, r *http.Request, familyID uuid.UUID) {
+	if err := h.TokenRepo.RevokeFamily(r.Context(), familyID); err != nil {
+		utils.RespondErr(w, err)
+		return
+	}
+	utils.RespondError(w, http.StatusUnauthorized, "Refresh token sudah digunakan, silakan login ulang", "refresh token reuse detected")
+}
+
+// newRefreshToken membuat refresh token baru beserta representasinya untuk disimpan di database
+func (h *AuthHandler) newRefreshToken(userID, familyID uuid.UUID) (string, *model.RefreshToken, error) {
+	plain, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(plain),
+		ExpiresAt: now.Add(h.Config.JWT.RefreshTokenTTL),
+		CreatedAt: now,
+	}
+	return plain, stored, nil
+}
+
+// newLoginResponse membuat access token untuk pengguna dan membungkusnya bersama refresh token
+func (h *AuthHandler) newLoginResponse(user *model.User, refreshToken string) (*model.LoginResponse, error) {
+	token, err := h.Tokens.GenerateToken(user.ID.String(), user.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.Tokens.AccessTokenTTL().Seconds()),
+	}, nil
+}
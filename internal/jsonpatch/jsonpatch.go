@@ -0,0 +1,307 @@
+// Package jsonpatch menerapkan JSON Merge Patch (RFC 7396) dan JSON Patch (RFC 6902) pada dokumen
+// JSON yang sudah di-decode ke any, yaitu kombinasi map[string]any, []any, string, float64, bool,
+// dan nil seperti hasil json.Unmarshal.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrTestFailed dikembalikan Apply jika operasi "test" tidak cocok dengan dokumen
+var ErrTestFailed = errors.New("test operation failed")
+
+// Operation adalah satu operasi JSON Patch. Value sengaja berupa json.RawMessage agar "value": null
+// bisa dibedakan dari value yang tidak dikirim.
+type Operation struct {
+	Op    string          `json:"op" enums:"add,remove,replace,move,copy,test" example:"replace"`
+	Path  string          `json:"path" example:"/price"`
+	From  string          `json:"from,omitempty"`                       // Hanya untuk move dan copy
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"` // Untuk add, replace, dan test
+}
+
+// OperationError menjelaskan operasi ke-Index (mulai dari 0) yang gagal diterapkan
+type OperationError struct {
+	Index int
+	Op    string
+	Err   error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *OperationError) Unwrap() error { return e.Err }
+
+// MergePatch menerapkan merge patch pada doc sesuai algoritma RFC 7396: objek digabung secara
+// rekursif, null menghapus field, dan nilai selain objek menggantikan target seluruhnya.
+// doc boleh ikut berubah.
+func MergePatch(doc, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	target, ok := doc.(map[string]any)
+	if !ok {
+		target = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		target[key] = MergePatch(target[key], value)
+	}
+	return target
+}
+
+// Apply menerapkan operasi secara berurutan pada doc dan mengembalikan hasilnya. Jika satu operasi
+// gagal, seluruh patch dianggap gagal (*OperationError, membungkus ErrTestFailed untuk operasi test
+// yang tidak cocok). doc boleh ikut berubah, jadi jangan dipakai lagi setelah Apply gagal.
+func Apply(doc any, ops []Operation) (any, error) {
+	for i, op := range ops {
+		var err error
+		if doc, err = applyOne(doc, op); err != nil {
+			return nil, &OperationError{Index: i, Op: op.Op, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+func applyOne(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New("value is required")
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			// replace sama dengan remove lalu add, tetapi target wajib sudah ada
+			if len(path) == 0 {
+				return value, nil
+			}
+			if doc, _, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: value at %q differs", ErrTestFailed, op.Path)
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		if op.Op == "copy" {
+			value, err := get(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, deepCopy(value))
+		}
+		if len(path) > len(from) && isPrefix(from, path) {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+
+	case "":
+		return nil, errors.New("op is required")
+	default:
+		return nil, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// parsePointer memecah JSON Pointer (RFC 6901) menjadi token, dengan ~1 menjadi / dan ~0 menjadi ~.
+// Pointer kosong berarti seluruh dokumen.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must be empty or start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex membaca token sebagai indeks array yang valid untuk panjang n. "-" (posisi setelah
+// elemen terakhir) hanya diterima jika allowEnd, yaitu untuk operasi add.
+func arrayIndex(token string, n int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || strings.HasPrefix(token, "+") {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > n || (i == n && !allowEnd) {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func get(node any, path []string) (any, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", token)
+			}
+			node = child
+		case []any:
+			i, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("path %q not found", token)
+		}
+	}
+	return node, nil
+}
+
+// add menambahkan value di path dan mengembalikan node yang sudah diubah. Field objek yang sudah
+// ada ditimpa, sedangkan pada array value disisipkan sebelum indeks tujuan.
+func add(node any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("path %q not found", token)
+		}
+		child, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+	case []any:
+		i, err := arrayIndex(token, len(n), len(rest) == 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		if n[i], err = add(n[i], rest, value); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("path %q not found", token)
+}
+
+// remove menghapus nilai di path dan mengembalikan node yang sudah diubah beserta nilai yang dihapus
+func remove(node any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	token, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("path %q not found", token)
+		}
+		if len(rest) == 0 {
+			delete(n, token)
+			return n, child, nil
+		}
+		child, removed, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = child
+		return n, removed, nil
+	case []any:
+		i, err := arrayIndex(token, len(n), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+		child, removed, err := remove(n[i], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = child
+		return n, removed, nil
+	}
+	return nil, nil, fmt.Errorf("path %q not found", token)
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, child := range v {
+			out[key] = deepCopy(child)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, child := range v {
+			out[i] = deepCopy(child)
+		}
+		return out
+	}
+	return value
+}
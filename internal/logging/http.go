@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader adalah header untuk menerima dan mengembalikan ID korelasi request
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength membatasi panjang request ID dari klien agar log tidak bisa dibanjiri
+const maxRequestIDLength = 128
+
+type requestStateKey struct{}
+
+// requestState menyimpan informasi yang baru diketahui di dalam rantai middleware (misal user ID
+// dari AuthMiddleware) agar bisa ikut ditulis pada log akhir request
+type requestState struct {
+	requestID string
+	userID    string
+}
+
+// Middleware memberi setiap request sebuah request ID (dari header X-Request-ID jika valid),
+// menyimpan logger ber-request_id di context, dan menulis satu baris log per request berisi
+// method, pola rute, status, ukuran respon, latensi, dan user ID.
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			state := &requestState{requestID: requestID}
+			ctx := context.WithValue(r.Context(), requestStateKey{}, state)
+			ctx = NewContext(ctx, logger.With("request_id", requestID))
+
+			ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := ""
+			if rctx := chi.RouteContext(ctx); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+
+			attrs := []slog.Attr{
+				slog.String("request_id", requestID),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", route),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.String("remote_addr", r.RemoteAddr),
+			}
+			if state.userID != "" {
+				attrs = append(attrs, slog.String("user_id", state.userID))
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(ctx, level, "request selesai", attrs...)
+		})
+	}
+}
+
+// SetUserID mencatat user yang terautentikasi untuk log request ini dan mengembalikan context
+// dengan logger yang sudah menyertakan user_id
+func SetUserID(ctx context.Context, userID string) context.Context {
+	if state, ok := ctx.Value(requestStateKey{}).(*requestState); ok {
+		state.userID = userID
+	}
+	return With(ctx, "user_id", userID)
+}
+
+// RequestID mengembalikan request ID dari context, atau string kosong di luar request HTTP
+func RequestID(ctx context.Context) string {
+	if state, ok := ctx.Value(requestStateKey{}).(*requestState); ok {
+		return state.requestID
+	}
+	return ""
+}
+
+// validRequestID menerima ID dari klien hanya jika pendek dan berisi karakter ASCII yang terlihat
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
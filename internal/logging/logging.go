@@ -0,0 +1,57 @@
+// Package logging menyediakan logger slog terstruktur dan cara membawanya lewat context,
+// sehingga log dari handler maupun repository memiliki request_id yang sama dengan log request-nya.
+// Package ini tidak mengimpor package internal lain agar bisa dipakai dari mana saja.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// Format output log yang didukung
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New membuat logger dengan level dan format yang diberikan. Format selain "text" menghasilkan JSON.
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == FormatText {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// ParseLevel mengubah "debug", "info", "warn", atau "error" (tidak peka huruf besar) menjadi slog.Level
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return level, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", s)
+	}
+	return level, nil
+}
+
+// NewContext menyimpan logger di context
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext mengambil logger dari context, atau slog.Default() jika tidak ada
+// (misalnya saat dipanggil dari luar request HTTP)
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// With menambahkan atribut ke logger di context, misal logging.With(ctx, "product_id", id)
+func With(ctx context.Context, args ...any) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
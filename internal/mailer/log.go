@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/logging"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LogMailer menulis email ke log alih-alih mengirimnya. Hanya untuk development:
+// isi email (termasuk token) ikut tercatat di log.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	logging.FromContext(ctx).InfoContext(ctx, "Email (tidak dikirim)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// FileMailer menyimpan setiap email sebagai file .eml di Dir, bisa dibuka dengan email client
+type FileMailer struct {
+	Dir  string
+	From string
+	seq  atomic.Uint64
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{Dir: dir, From: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if hasHeaderInjection(msg.To, msg.Subject) {
+		return errors.New("mailer: invalid header value")
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	now := time.Now()
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", `\`, "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%04d-%s.eml", now.Format("20060102T150405"), m.seq.Add(1), recipient)
+	return os.WriteFile(filepath.Join(m.Dir, name), format(m.From, msg, now), 0o600)
+}
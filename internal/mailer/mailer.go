@@ -0,0 +1,47 @@
+// Package mailer mengirim email transaksional (verifikasi email, reset password).
+// Implementasi SMTP dipakai di produksi; LogMailer dan FileMailer memudahkan pengujian lokal
+// karena isi email, termasuk tautan token, bisa dibaca tanpa server email.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message adalah satu email teks biasa
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email. Implementasi harus aman dipanggil dari banyak goroutine.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// format menyusun email dalam format RFC 5322 sederhana (UTF-8, teks biasa)
+func format(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// hasHeaderInjection menolak nilai header yang berisi baris baru
+func hasHeaderInjection(values ...string) bool {
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return true
+		}
+	}
+	return false
+}
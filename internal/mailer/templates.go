@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"fmt"
+	"time"
+)
+
+// VerificationEmail menyusun email berisi tautan verifikasi alamat email
+func VerificationEmail(to, name, link string, ttl time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Verifikasi alamat email Anda",
+		Body: fmt.Sprintf(`Halo %s,
+
+Terima kasih telah mendaftar. Buka tautan berikut untuk memverifikasi alamat email Anda:
+
+%s
+
+Tautan ini berlaku selama %s dan hanya bisa dipakai sekali.
+Abaikan email ini jika Anda tidak merasa mendaftar.
+`, name, link, humanDuration(ttl)),
+	}
+}
+
+// PasswordResetEmail menyusun email berisi tautan reset password
+func PasswordResetEmail(to, name, link string, ttl time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Reset password akun Anda",
+		Body: fmt.Sprintf(`Halo %s,
+
+Kami menerima permintaan untuk mereset password akun Anda. Buka tautan berikut untuk membuat password baru:
+
+%s
+
+Tautan ini berlaku selama %s dan hanya bisa dipakai sekali. Setelah password diganti, semua sesi yang sedang aktif akan dikeluarkan.
+Abaikan email ini jika Anda tidak meminta reset password; password Anda tidak berubah.
+`, name, link, humanDuration(ttl)),
+	}
+}
+
+// EmailChangeEmail menyusun email ke alamat baru berisi tautan konfirmasi penggantian email
+func EmailChangeEmail(to, name, link string, ttl time.Duration) Message {
+	return Message{
+		To:      to,
+		Subject: "Konfirmasi alamat email baru Anda",
+		Body: fmt.Sprintf(`Halo %s,
+
+Kami menerima permintaan untuk mengganti email akun Anda ke alamat ini. Buka tautan berikut untuk mengonfirmasi:
+
+%s
+
+Tautan ini berlaku selama %s dan hanya bisa dipakai sekali. Email akun tidak berubah sebelum tautan dibuka.
+Abaikan email ini jika Anda tidak meminta penggantian email.
+`, name, link, humanDuration(ttl)),
+	}
+}
+
+// EmailChangedNotice menyusun pemberitahuan ke alamat lama bahwa email akun telah diganti
+func EmailChangedNotice(to, name, newEmail string) Message {
+	return Message{
+		To:      to,
+		Subject: "Email akun Anda telah diganti",
+		Body: fmt.Sprintf(`Halo %s,
+
+Email akun Anda baru saja diganti menjadi %s. Email berikutnya akan dikirim ke alamat tersebut.
+Jika Anda tidak melakukan perubahan ini, segera hubungi tim dukungan kami.
+`, name, newEmail),
+	}
+}
+
+// humanDuration menulis durasi dalam bahasa sehari-hari, misal "24 jam" atau "30 menit"
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d jam", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%d menit", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,104 @@
+// Package metrics mendefinisikan metrik Prometheus layanan ini dan handler /metrics.
+// Semua metrik didaftarkan ke Registry milik package ini (bukan registry global) sehingga
+// isi /metrics sepenuhnya terkontrol. Package ini tidak mengimpor package internal lain
+// agar bisa dipakai dari handler, middleware, maupun utils tanpa import cycle.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gochi"
+
+// Registry berisi semua metrik yang diekspos di /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests menghitung request per pola rute chi (misal /products/{id}), bukan path mentah,
+	// agar jumlah label tidak meledak karena ID di URL
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	// Logins menghitung percobaan login; result bernilai "success" atau "failure"
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	// TokenValidationFailures menghitung access token yang ditolak AuthMiddleware per alasan
+	TokenValidationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_token_validation_failures_total",
+		Help:      "Access tokens rejected by the auth middleware, by reason.",
+	}, []string{"reason"})
+
+	// PasswordHashDuration mengukur lama operasi bcrypt; op bernilai "hash" atau "compare"
+	PasswordHashDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "auth_password_hash_duration_seconds",
+		Help:      "Duration of bcrypt password operations.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 4},
+	}, []string{"op"})
+)
+
+// Alasan penolakan token untuk TokenValidationFailures
+const (
+	ReasonMissingHeader   = "missing_header"
+	ReasonMalformedHeader = "malformed_header"
+	ReasonInvalidToken    = "invalid_token"
+	ReasonRevoked         = "revoked"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		Logins,
+		TokenValidationFailures,
+		PasswordHashDuration,
+	)
+}
+
+// Handler mengembalikan handler /metrics dalam format eksposisi Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveLogin mencatat hasil satu percobaan login
+func ObserveLogin(success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	Logins.WithLabelValues(result).Inc()
+}
+
+// ObservePasswordHash mencatat lama operasi bcrypt yang dimulai pada start
+func ObservePasswordHash(op string, start time.Time) {
+	PasswordHashDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
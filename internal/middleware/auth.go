@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
+	"gochi-boilerplate/internal/logging"
+	"gochi-boilerplate/internal/metrics"
 	"gochi-boilerplate/internal/utils"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // ContextKey adalah tipe custom untuk kunci context agar tidak bentrok
@@ -12,29 +15,60 @@ type ContextKey string
 
 const UserClaimsKey ContextKey = "userClaims"
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			utils.RespondError(w, http.StatusUnauthorized, "Header Authorization dibutuhkan", "missing auth header")
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			utils.RespondError(w, http.StatusUnauthorized, "Format header Authorization salah", "format must be Bearer <token>")
-			return
-		}
-
-		tokenString := parts[1]
-		claims, err := utils.ValidateToken(tokenString)
-		if err != nil {
-			utils.RespondError(w, http.StatusUnauthorized, "Token tidak valid", err.Error())
-			return
-		}
-
-		// Simpan claims di context agar bisa diakses oleh handler selanjutnya
-		ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
\ No newline at end of file
+// TokenRevocationChecker memeriksa apakah sebuah access token sudah dicabut sebelum kedaluwarsa
+type TokenRevocationChecker interface {
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+}
+
+// AuthMiddleware memvalidasi JWT pada header Authorization dan menolak token yang sudah dicabut
+func AuthMiddleware(tokens *utils.TokenManager, revocations TokenRevocationChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				metrics.TokenValidationFailures.WithLabelValues(metrics.ReasonMissingHeader).Inc()
+				utils.RespondError(w, http.StatusUnauthorized, "Header Authorization dibutuhkan", "missing auth header")
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+				metrics.TokenValidationFailures.WithLabelValues(metrics.ReasonMalformedHeader).Inc()
+				utils.RespondError(w, http.StatusUnauthorized, "Format header Authorization salah", "format must be Bearer <token>")
+				return
+			}
+
+			tokenString := parts[1]
+			claims, err := tokens.ValidateToken(tokenString)
+			if err != nil {
+				metrics.TokenValidationFailures.WithLabelValues(metrics.ReasonInvalidToken).Inc()
+				utils.RespondError(w, http.StatusUnauthorized, "Token tidak valid", err.Error())
+				return
+			}
+
+			var issuedAt time.Time
+			if claims.IssuedAt != nil {
+				issuedAt = claims.IssuedAt.Time
+			}
+			revoked, err := revocations.IsRevoked(r.Context(), claims.ID, claims.UserID, issuedAt)
+			if err != nil {
+				utils.RespondError(w, http.StatusServiceUnavailable, "Gagal memeriksa status token", err.Error())
+				return
+			}
+			if revoked {
+				metrics.TokenValidationFailures.WithLabelValues(metrics.ReasonRevoked).Inc()
+				utils.RespondError(w, http.StatusUnauthorized, "Token sudah dicabut", "token revoked")
+				return
+			}
+
+			// Simpan claims di context agar bisa diakses oleh handler selanjutnya
+			ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
+			ctx = logging.SetUserID(ctx, claims.UserID)
+			if claims.IsImpersonation() {
+				// Semua log selama impersonasi mencatat admin yang sebenarnya bertindak
+				ctx = logging.With(ctx, "impersonated_by", claims.ImpersonatedBy)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/utils"
+	"net/http"
+)
+
+// RequirePermission hanya meneruskan request jika peran pengguna memiliki semua hak akses perms.
+// Harus dipasang setelah AuthMiddleware.
+func RequirePermission(perms ...model.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(UserClaimsKey).(*utils.Claims)
+			if !ok {
+				utils.RespondError(w, http.StatusUnauthorized, "Autentikasi dibutuhkan", "missing user claims")
+				return
+			}
+
+			for _, perm := range perms {
+				if !model.HasPermission(claims.Role, perm) {
+					utils.RespondError(w, http.StatusForbidden, "Akses ditolak", "missing permission: "+string(perm))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAdmin hanya meneruskan request dari admin yang login dengan tokennya sendiri.
+// Token impersonasi ditolak walaupun pengguna yang di-impersonasi adalah admin, agar
+// impersonasi tidak bisa dipakai untuk tindakan admin lanjutan. Harus dipasang setelah AuthMiddleware.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(UserClaimsKey).(*utils.Claims)
+		if !ok {
+			utils.RespondError(w, http.StatusUnauthorized, "Autentikasi dibutuhkan", "missing user claims")
+			return
+		}
+		if claims.IsImpersonation() {
+			utils.RespondError(w, http.StatusForbidden, "Akses ditolak selama impersonasi", "impersonation token cannot access admin endpoints")
+			return
+		}
+		if claims.Role != model.RoleAdmin {
+			utils.RespondError(w, http.StatusForbidden, "Akses ditolak", "requires role: "+model.RoleAdmin)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSortFields adalah kolom yang boleh dipakai pada parameter sort daftar pengguna
+var UserSortFields = []string{"full_name", "email", "created_at", "updated_at"}
+
+// UserFilter berisi filter opsional untuk daftar pengguna di panel admin; nilai nil/kosong berarti tidak difilter
+type UserFilter struct {
+	Query    string // Dicocokkan dengan nama atau email (case-insensitive)
+	Role     string
+	Disabled *bool
+}
+
+// UpdateRoleRequest adalah model untuk body request ganti peran pengguna
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,role" enums:"admin,editor,auditor,user" example:"editor"`
+}
+
+// ImpersonationResponse berisi access token untuk bertindak sebagai pengguna lain.
+// Tidak ada refresh token; sesi impersonasi berakhir saat token kedaluwarsa.
+type ImpersonationResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type" example:"Bearer"`
+	ExpiresIn int       `json:"expires_in" example:"900"` // Masa berlaku token dalam detik
+	User      *User     `json:"user"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AdminAction adalah jenis tindakan admin yang dicatat di audit log, harus sama dengan ENUM 'admin_action' di database
+type AdminAction string
+
+const (
+	AdminActionChangeRole         AdminAction = "change_role"
+	AdminActionDisable            AdminAction = "disable"
+	AdminActionEnable             AdminAction = "enable"
+	AdminActionForcePasswordReset AdminAction = "force_password_reset"
+	AdminActionImpersonate        AdminAction = "impersonate"
+)
+
+// AuditEntry adalah satu catatan tindakan admin terhadap akun pengguna
+type AuditEntry struct {
+	ID           uuid.UUID      `json:"id"`
+	ActorID      uuid.UUID      `json:"actor_id"`
+	Action       AdminAction    `json:"action"`
+	TargetUserID uuid.UUID      `json:"target_user_id"`
+	Details      map[string]any `json:"details"` // Konteks tambahan, misal peran lama dan baru
+	CreatedAt    time.Time      `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Batas default dan maksimum jumlah item per halaman
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// MaxOffset adalah jumlah maksimum baris yang boleh dilewati pada mode offset. Nomor halaman dibatasi
+// agar (page-1)*per_page tidak melampauinya dan tidak overflow menjadi OFFSET negatif.
+const MaxOffset = math.MaxInt32
+
+// SortField adalah satu kolom pengurutan, misal "-created_at" menjadi {Field: "created_at", Desc: true}
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Cursor menandai item terakhir pada paginasi keyset (created_at, id)
+type Cursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// PageParams berisi parameter paginasi dan pengurutan untuk query daftar.
+// Ada dua mode: keyset (Limit + After) yang stabil untuk data besar,
+// dan offset (Page + Limit) yang menyertakan total data.
+type PageParams struct {
+	Limit int
+	Page  int     // Terisi (>= 1) hanya pada mode offset
+	After *Cursor // Posisi awal pada mode keyset, nil untuk halaman pertama
+	Sort  []SortField
+}
+
+// OffsetMode menandakan apakah paginasi memakai page/per_page
+func (p PageParams) OffsetMode() bool {
+	return p.Page > 0
+}
+
+// MaxPage mengembalikan nomor halaman terbesar untuk limit tertentu sehingga Offset tidak melebihi MaxOffset
+func MaxPage(limit int) int {
+	return MaxOffset/limit + 1
+}
+
+// Offset menghitung jumlah baris yang dilewati pada mode offset
+func (p PageParams) Offset() int {
+	if p.Page <= 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.Limit
+}
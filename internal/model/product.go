@@ -1,26 +1,60 @@
 package model
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Product struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Price     int       `json:"price"`
-	UserID    *uuid.UUID `json:"user_id,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID    `json:"id"`
+	Name      string       `json:"name"`
+	Price     int          `json:"price"`
+	UserID    *uuid.UUID   `json:"user_id,omitempty"`
+	Owner     *UserSummary `json:"owner,omitempty"` // Hanya terisi jika diminta dengan ?include=owner
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Version   int          `json:"version" example:"1"`  // Naik setiap kali produk berubah; dikirim juga sebagai ETag
+	DeletedAt *time.Time   `json:"deleted_at,omitempty"` // Terisi jika produk ada di trash
 }
 
 type CreateProductRequest struct {
-	Name  string `json:"name" example:"Laptop Gaming"`
-	Price int    `json:"price" example:"15000000"`
+	Name  string `json:"name" validate:"required,notblank,max=255" example:"Laptop Gaming"`
+	Price int    `json:"price" validate:"gte=0" minimum:"0" example:"15000000"`
 }
 
-type UpdateProductRequest struct {
-	Name  *string `json:"name,omitempty"`
-	Price *int    `json:"price,omitempty"`
-}
\ No newline at end of file
+// ReplaceProductRequest berisi semua field produk yang bisa diubah. PUT menggantikan seluruhnya,
+// sedangkan PATCH menerapkan patch pada dokumen berbentuk sama lalu memvalidasi hasilnya.
+type ReplaceProductRequest struct {
+	Name  string `json:"name" validate:"required,notblank,max=255" example:"Laptop Gaming"`
+	Price *int   `json:"price" validate:"required,gte=0" minimum:"0" example:"15000000"` // Pointer agar price yang tidak dikirim bisa dibedakan dari 0
+}
+
+// ProductSearchResult adalah satu hasil pencarian full-text beserta skor relevansinya
+type ProductSearchResult struct {
+	Product
+	Rank      float32 `json:"rank" example:"0.0607927"`
+	Highlight string  `json:"highlight" example:"<mark>Laptop</mark> Gaming"` // Nama produk yang sudah di-escape HTML, dengan kata yang cocok ditandai <mark>
+}
+
+// ProductInclude menentukan relasi tambahan yang ikut diambil bersama produk
+type ProductInclude struct {
+	Owner bool
+}
+
+// ProductSortFields adalah kolom yang boleh dipakai pada parameter sort daftar produk
+var ProductSortFields = []string{"name", "price", "created_at", "updated_at"}
+
+// TrashSortFields adalah kolom yang boleh dipakai pada parameter sort daftar trash
+var TrashSortFields = append(slices.Clone(ProductSortFields), "deleted_at")
+
+// ProductFilter berisi filter opsional untuk daftar produk; nilai nil/kosong berarti tidak difilter
+type ProductFilter struct {
+	MinPrice     *int
+	MaxPrice     *int
+	OwnerID      *uuid.UUID
+	Query        string // Dicocokkan dengan nama produk (case-insensitive)
+	CreatedAfter *time.Time
+	Trashed      bool // true: hanya produk di trash; false (default): hanya produk yang belum dihapus
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit adalah kapasitas token bucket: paling banyak Requests request sekaligus (burst),
+// dan bucket terisi penuh kembali dalam Period
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseRateLimit membaca batas berformat "<jumlah>/<periode>", misal "10/1m", "300/1h", atau "5/s"
+func ParseRateLimit(s string) (RateLimit, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, expected <requests>/<period> such as 10/1m", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: request count must be a positive integer", s)
+	}
+	// Satuan tanpa angka ("s", "m", "h") berarti satu satuan
+	if period != "" && !strings.ContainsAny(period[:1], "0123456789") {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+	return RateLimit{Requests: n, Period: d}, nil
+}
+
+func (l RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// RefillRate adalah jumlah token yang bertambah per detik
+func (l RateLimit) RefillRate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
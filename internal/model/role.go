@@ -0,0 +1,68 @@
+package model
+
+import "slices"
+
+// Daftar peran pengguna, harus sama dengan nilai ENUM 'user_role' di database
+const (
+	RoleAdmin   = "admin"
+	RoleEditor  = "editor"
+	RoleAuditor = "auditor"
+	RoleUser    = "user"
+)
+
+// Permission adalah hak akses atas satu aksi, dengan format "<resource>:<aksi>[:any]".
+// Akhiran ":any" berarti aksi boleh dilakukan pada data milik pengguna lain.
+type Permission string
+
+const (
+	PermProductsRead      Permission = "products:read"
+	PermProductsCreate    Permission = "products:create"
+	PermProductsUpdate    Permission = "products:update"
+	PermProductsUpdateAny Permission = "products:update:any"
+	PermProductsDelete    Permission = "products:delete"
+	PermProductsDeleteAny Permission = "products:delete:any"
+	PermProductsPurge     Permission = "products:purge" // Hapus permanen, melewati trash
+)
+
+// RolePermissions memetakan setiap peran ke hak akses yang dimilikinya.
+// Peran baru cukup ditambahkan di sini (dan di ENUM database) tanpa mengubah handler.
+var RolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermProductsRead, PermProductsCreate,
+		PermProductsUpdate, PermProductsUpdateAny,
+		PermProductsDelete, PermProductsDeleteAny,
+		PermProductsPurge,
+	},
+	RoleEditor: {
+		PermProductsRead, PermProductsCreate,
+		PermProductsUpdate, PermProductsUpdateAny,
+		PermProductsDelete,
+	},
+	RoleAuditor: {
+		PermProductsRead,
+	},
+	RoleUser: {
+		PermProductsRead, PermProductsCreate,
+		PermProductsUpdate, PermProductsDelete,
+	},
+}
+
+// Roles mengembalikan daftar peran yang dikenal secara terurut, dipakai untuk validasi dan pesan error
+func Roles() []string {
+	roles := make([]string, 0, len(RolePermissions))
+	for role := range RolePermissions {
+		roles = append(roles, role)
+	}
+	slices.Sort(roles)
+	return roles
+}
+
+// HasPermission memeriksa apakah sebuah peran memiliki hak akses tertentu
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range RolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
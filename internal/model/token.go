@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken struct sesuai dengan tabel 'refresh_tokens' di database.
+// Token asli tidak pernah disimpan, hanya hash SHA-256 nya.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id"` // Semua token hasil rotasi dari satu login berbagi family yang sama
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// RefreshTokenRequest adalah model untuk body request refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=128" example:"q1w2e3r4t5y6u7i8o9p0"`
+}
+
+// LogoutRequest adalah model untuk body request logout (opsional).
+// Jika refresh_token dikirim, seluruh family refresh token tersebut ikut dicabut.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty" validate:"omitempty,max=128" example:"q1w2e3r4t5y6u7i8o9p0"`
+}
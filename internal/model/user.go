@@ -8,29 +8,83 @@ import (
 
 // User struct sesuai dengan tabel 'users' di database
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	FullName  string    `json:"full_name"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"` // Jangan pernah kirim password hash ke klien
-	Role      string    `json:"role"`
+	ID       uuid.UUID `json:"id"`
+	FullName string    `json:"full_name"`
+	Email    string    `json:"email"`
+	Password string    `json:"-"` // Jangan pernah kirim password hash ke klien
+	Role     string    `json:"role"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"` // nil jika email belum diverifikasi
+
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"-"` // Login ditolak sampai waktu ini setelah terlalu banyak percobaan gagal
+
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`   // Terisi jika akun dinonaktifkan admin
+	PasswordResetRequired bool       `json:"password_reset_required"` // Login ditolak sampai password direset
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// IsLocked menandakan akun sedang dikunci karena terlalu banyak login gagal
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(now)
+}
+
+// IsDisabled menandakan akun dinonaktifkan oleh admin
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// LockoutPolicy mengatur penguncian akun setelah login gagal berulang kali
+type LockoutPolicy struct {
+	Threshold int           // Jumlah login gagal berturut-turut sebelum akun dikunci
+	Base      time.Duration // Lama penguncian pertama; berlipat dua setiap kegagalan berikutnya
+	Max       time.Duration // Batas atas lama penguncian
+}
+
+// UserSummary adalah data publik pengguna yang aman ditampilkan ke pengguna lain
+type UserSummary struct {
+	ID       uuid.UUID `json:"id"`
+	FullName string    `json:"full_name" example:"John Doe"`
+}
+
 // RegisterRequest adalah model untuk body request registrasi
 type RegisterRequest struct {
-	FullName string `json:"full_name" example:"John Doe"`
-	Email    string `json:"email" example:"john.doe@example.com"`
-	Password string `json:"password" example:"OnlinePHP"`
+	FullName string `json:"full_name" validate:"required,notblank,max=255" example:"John Doe"`
+	Email    string `json:"email" validate:"required,email,max=255" format:"email" example:"john.doe@example.com"`
+	Password string `json:"password" validate:"required,min=8,maxbytes=72" example:"OnlinePHP"` // bcrypt menolak password lebih dari 72 byte
 }
 
 // LoginRequest adalah model untuk body request login
 type LoginRequest struct {
-	Email    string `json:"email" example:"john.doe@example.com"`
-	Password string `json:"password" example:"OnlinePHP"`
+	Email    string `json:"email" validate:"required,email,max=255" format:"email" example:"john.doe@example.com"`
+	Password string `json:"password" validate:"required,maxbytes=72" example:"OnlinePHP"`
 }
 
-// LoginResponse adalah model untuk respon setelah login sukses
+// LoginResponse adalah model untuk respon setelah login atau refresh token sukses
 type LoginResponse struct {
-	Token string `json:"token"`
-}
\ No newline at end of file
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int    `json:"expires_in" example:"900"` // Masa berlaku access token dalam detik
+}
+
+// UpdateProfileRequest adalah model untuk body request PATCH /me. Field yang tidak dikirim tidak diubah.
+// Mengganti email memerlukan password saat ini, dan email baru baru dipakai setelah dikonfirmasi lewat tautan.
+type UpdateProfileRequest struct {
+	FullName        *string `json:"full_name,omitempty" validate:"omitempty,notblank,max=255" example:"John Doe"`
+	Email           *string `json:"email,omitempty" validate:"omitempty,email,max=255" format:"email" example:"john.doe@example.com"`
+	CurrentPassword string  `json:"current_password,omitempty" validate:"required_with=Email,maxbytes=72" example:"OnlinePHP"` // Wajib jika email dikirim
+}
+
+// ChangePasswordRequest adalah model untuk body request ganti password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required,maxbytes=72" example:"OnlinePHP"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,maxbytes=72,nefield=CurrentPassword" example:"NewSecret123"`
+}
+
+// DeleteAccountRequest adalah model untuk body request hapus akun; password diminta sebagai konfirmasi
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required,maxbytes=72" example:"OnlinePHP"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tujuan token sekali pakai, sesuai ENUM user_token_purpose di database
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeChangeEmail   = "change_email" // Email di token adalah alamat baru yang menunggu konfirmasi
+)
+
+// UserToken struct sesuai dengan tabel 'user_tokens' di database.
+// Token dikirim ke email pengguna dan hanya bisa dipakai sekali sebelum kedaluwarsa.
+type UserToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   string
+	TokenHash string
+	Email     string // Alamat tujuan token
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// VerifyEmailRequest adalah model untuk body request verifikasi email
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required,max=128" example:"q1w2e3r4t5y6u7i8o9p0"`
+}
+
+// EmailRequest adalah model untuk body request yang hanya berisi email, seperti lupa password
+// dan kirim ulang email verifikasi
+type EmailRequest struct {
+	Email string `json:"email" validate:"required,email,max=255" format:"email" example:"john.doe@example.com"`
+}
+
+// ResetPasswordRequest adalah model untuk body request reset password
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required,max=128" example:"q1w2e3r4t5y6u7i8o9p0"`
+	NewPassword string `json:"new_password" validate:"required,min=8,maxbytes=72" example:"NewSecret123"`
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"gochi-boilerplate/internal/logging"
+	"gochi-boilerplate/internal/middleware"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/utils"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// KeyFunc menentukan kepada siapa batas berlaku untuk sebuah request
+type KeyFunc func(r *http.Request) string
+
+// ByIP membatasi per alamat IP klien. Jika server berada di belakang proxy, pasang
+// chiMiddleware.RealIP lebih dulu agar RemoteAddr berisi IP klien yang sebenarnya.
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ByUser membatasi per pengguna yang terautentikasi, dengan IP sebagai cadangan.
+// Harus dipasang setelah AuthMiddleware.
+func ByUser(r *http.Request) string {
+	if claims, ok := r.Context().Value(middleware.UserClaimsKey).(*utils.Claims); ok {
+		return "user:" + claims.UserID
+	}
+	return ByIP(r)
+}
+
+// Middleware menolak request dengan 429 dan header Retry-After jika bucket milik key sudah habis.
+// name membedakan bucket antar grup rute, sehingga satu IP punya kuota terpisah untuk /auth dan /products.
+// Jika store gagal (misal backend bersama tidak bisa dihubungi), request tetap dilewatkan.
+func Middleware(store Store, name string, limit model.RateLimit, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := store.Take(r.Context(), name+":"+key(r), limit)
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("Rate limiter tidak tersedia, request dilewatkan", "error", err, "limiter", name)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			if !res.Allowed {
+				utils.RespondErr(w, &utils.RateLimitError{RetryAfter: res.RetryAfter})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package ratelimit membatasi jumlah request dengan algoritma token bucket. Penyimpanan bucket
+// dipisah lewat interface Store: MemoryStore cukup untuk satu instance, sedangkan deployment
+// dengan banyak replika bisa memasang backend bersama (misal Redis dengan skrip Lua) tanpa
+// mengubah middleware.
+package ratelimit
+
+import (
+	"context"
+	"gochi-boilerplate/internal/model"
+	"math"
+	"sync"
+	"time"
+)
+
+// Result adalah hasil pengambilan satu token
+type Result struct {
+	Allowed    bool
+	Remaining  int           // Sisa token setelah request ini
+	RetryAfter time.Duration // Waktu tunggu sampai satu token tersedia, jika ditolak
+}
+
+// Store menyimpan status token bucket per key. Implementasi harus atomik per key karena
+// dipanggil bersamaan dari banyak request.
+type Store interface {
+	Take(ctx context.Context, key string, limit model.RateLimit) (Result, error)
+}
+
+// MemoryStore adalah Store di memori proses; batasnya berlaku per instance
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // Waktu bucket akan penuh kembali; setelah itu entri boleh dibuang
+}
+
+// sweepInterval adalah jarak minimal antar pembersihan bucket yang sudah penuh kembali
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit model.RateLimit) (Result, error) {
+	now := s.now()
+	rate := limit.RefillRate()
+	capacity := float64(limit.Requests)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	var res Result
+	if b.tokens >= 1 {
+		b.tokens--
+		res = Result{Allowed: true, Remaining: int(b.tokens)}
+	} else {
+		wait := (1 - b.tokens) / rate
+		res = Result{RetryAfter: time.Duration(wait * float64(time.Second))}
+	}
+	b.full = now.Add(time.Duration((capacity - b.tokens) / rate * float64(time.Second)))
+	return res, nil
+}
+
+// sweep membuang bucket yang sudah penuh kembali, karena bucket baru akan berperilaku sama.
+// Dipanggil di bawah lock.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for k, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, k)
+		}
+	}
+	s.lastSweep = now
+}
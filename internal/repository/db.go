@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBTX adalah bagian dari *pgxpool.Pool dan pgx.Tx yang dipakai repository. Dengan begitu repository
+// bisa dijalankan di atas pool maupun di dalam transaksi yang sudah ada (misal test integrasi yang
+// di-rollback). Begin pada pgx.Tx membuat savepoint, sehingga transaksi internal repository tetap bekerja.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/logging"
+	"net"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error domain yang dikembalikan repository. Handler cukup memakai errors.Is terhadap
+// nilai-nilai ini tanpa perlu mengenal pgx atau kode error Postgres.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")             // Data duplikat, misal email yang sudah terdaftar
+	ErrConstraint  = errors.New("constraint violation") // Data melanggar aturan skema (CHECK, foreign key, NOT NULL)
+	ErrUnavailable = errors.New("database unavailable") // Database tidak bisa dihubungi atau sedang overload
+	ErrStale       = errors.New("stale version")        // Data sudah diubah pihak lain sejak dibaca (optimistic locking)
+)
+
+// DBError membungkus error asli dari database beserta jenis error domain-nya
+type DBError struct {
+	Kind       error  // Salah satu dari ErrNotFound, ErrConflict, ErrConstraint, ErrUnavailable, ErrStale
+	Constraint string // Nama constraint yang dilanggar, jika ada
+	Err        error
+}
+
+func (e *DBError) Error() string {
+	if e.Constraint != "" {
+		return fmt.Sprintf("%v: %s (%v)", e.Kind, e.Constraint, e.Err)
+	}
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+// Unwrap membuat errors.Is bekerja terhadap jenis error domain maupun error aslinya
+func (e *DBError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// translateError menerjemahkan error pgx/Postgres menjadi error domain.
+// Error yang tidak dikenali dikembalikan apa adanya. Error yang tidak diharapkan (database
+// tidak tersedia atau error yang tidak dikenali) ditulis ke log request di ctx, sehingga
+// memiliki request_id yang sama dengan log HTTP-nya.
+func translateError(ctx context.Context, err error) error {
+	var dbErr *DBError
+	if err == nil || errors.As(err, &dbErr) {
+		return err
+	}
+
+	translated := classifyError(err)
+	if errors.As(translated, &dbErr) && dbErr.Kind != ErrUnavailable {
+		// Not found, konflik, dan pelanggaran constraint adalah hasil yang wajar dan dipetakan ke 4xx
+		return translated
+	}
+
+	attrs := []any{"error", err}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		attrs = append(attrs, "pg_code", pgErr.Code)
+	}
+	logging.FromContext(ctx).ErrorContext(ctx, "query database gagal", attrs...)
+	return translated
+}
+
+// classifyError memetakan error pgx/Postgres ke DBError dengan jenis error domain yang sesuai
+func classifyError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &DBError{Kind: ErrNotFound, Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505": // unique_violation
+			return &DBError{Kind: ErrConflict, Constraint: pgErr.ConstraintName, Err: err}
+		case pgErr.Code == "23503", // foreign_key_violation
+			pgErr.Code == "23514", // check_violation
+			pgErr.Code == "23502", // not_null_violation
+			pgErr.Code == "23P01", // exclusion_violation
+			pgErr.Code == "22001", // string_data_right_truncation
+			pgErr.Code == "22P02": // invalid_text_representation, misal nilai ENUM tidak dikenal
+			return &DBError{Kind: ErrConstraint, Constraint: pgErr.ConstraintName, Err: err}
+		case pgErr.Code[:2] == "08", // connection_exception
+			pgErr.Code[:2] == "53", // insufficient_resources
+			pgErr.Code == "57P01",  // admin_shutdown
+			pgErr.Code == "57P03":  // cannot_connect_now
+			return &DBError{Kind: ErrUnavailable, Err: err}
+		}
+		return err
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) || pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return &DBError{Kind: ErrUnavailable, Err: err}
+	}
+	return err
+}
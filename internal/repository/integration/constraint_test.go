@@ -0,0 +1,190 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TestConstraintErrors memastikan setiap pelanggaran skema diterjemahkan menjadi error domain yang
+// dipetakan handler ke status HTTP (409, 422, 404), lengkap dengan nama constraint-nya. Perubahan
+// migrasi yang mengganti constraint atau jenis error akan terlihat di diff file golden.
+// Perbarui dengan: go test -tags integration -run TestConstraintErrors -update ./internal/repository/integration/
+func TestConstraintErrors(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	user := createUser(t, tx)
+	product := createProduct(t, tx, user, "Produk", 10, now())
+	refresh := createRefreshToken(t, tx, user, uuid.New())
+	ts := now()
+
+	newUser := func(mutate func(u *model.User)) *model.User {
+		u := &model.User{ID: uuid.New(), FullName: "Baru", Email: uuid.NewString() + "@example.com", Password: "hash",
+			Role: model.RoleUser, CreatedAt: ts, UpdatedAt: ts}
+		mutate(u)
+		return u
+	}
+	newUserToken := func(mutate func(tok *model.UserToken)) *model.UserToken {
+		tok := &model.UserToken{ID: uuid.New(), UserID: user.ID, Purpose: model.TokenPurposeVerifyEmail,
+			TokenHash: uuid.NewString(), Email: user.Email, ExpiresAt: ts.Add(time.Hour), CreatedAt: ts}
+		mutate(tok)
+		return tok
+	}
+	audit := func(action model.AdminAction) *model.AuditEntry {
+		return &model.AuditEntry{ActorID: user.ID, Action: action, TargetUserID: user.ID}
+	}
+
+	cases := []struct {
+		name string
+		fn   func(db repository.DBTX) error
+	}{
+		{"user duplicate email", func(db repository.DBTX) error {
+			return repository.NewUserRepository(db).CreateUser(ctx, newUser(func(u *model.User) { u.Email = user.Email }))
+		}},
+		{"user duplicate id", func(db repository.DBTX) error {
+			return repository.NewUserRepository(db).CreateUser(ctx, newUser(func(u *model.User) { u.ID = user.ID }))
+		}},
+		{"user unknown role", func(db repository.DBTX) error {
+			return repository.NewUserRepository(db).CreateUser(ctx, newUser(func(u *model.User) { u.Role = "superuser" }))
+		}},
+		{"user name too long", func(db repository.DBTX) error {
+			return repository.NewUserRepository(db).CreateUser(ctx, newUser(func(u *model.User) { u.FullName = strings.Repeat("a", 256) }))
+		}},
+		{"user profile duplicate email", func(db repository.DBTX) error {
+			other := createUser(t, db)
+			other.Email = user.Email
+			return repository.NewUserRepository(db).UpdateProfile(ctx, other)
+		}},
+		{"user role update unknown role", func(db repository.DBTX) error {
+			return repository.NewUserRepository(db).UpdateRole(ctx, user.ID, "superuser", audit(model.AdminActionChangeRole))
+		}},
+		{"user missing", func(db repository.DBTX) error {
+			_, err := repository.NewUserRepository(db).GetUserByID(ctx, uuid.New())
+			return err
+		}},
+		{"audit unknown action", func(db repository.DBTX) error {
+			return repository.NewUserRepository(db).RecordAudit(ctx, audit(model.AdminAction("delete")))
+		}},
+		{"product negative price", func(db repository.DBTX) error {
+			return repository.NewProductRepository(db).CreateProduct(ctx, &model.Product{ID: uuid.New(), Name: "X", Price: -1, CreatedAt: ts, UpdatedAt: ts})
+		}},
+		{"product update negative price", func(db repository.DBTX) error {
+			p := *product
+			p.Price = -1
+			return repository.NewProductRepository(db).UpdateProduct(ctx, &p)
+		}},
+		{"product unknown owner", func(db repository.DBTX) error {
+			owner := uuid.New()
+			return repository.NewProductRepository(db).CreateProduct(ctx, &model.Product{ID: uuid.New(), Name: "X", UserID: &owner, CreatedAt: ts, UpdatedAt: ts})
+		}},
+		{"product duplicate id", func(db repository.DBTX) error {
+			return repository.NewProductRepository(db).CreateProduct(ctx, &model.Product{ID: product.ID, Name: "X", CreatedAt: ts, UpdatedAt: ts})
+		}},
+		{"product missing", func(db repository.DBTX) error {
+			return repository.NewProductRepository(db).DeleteProduct(ctx, uuid.New(), 1)
+		}},
+		{"product update stale version", func(db repository.DBTX) error {
+			p := *product
+			p.Version++
+			return repository.NewProductRepository(db).UpdateProduct(ctx, &p)
+		}},
+		{"refresh token duplicate hash", func(db repository.DBTX) error {
+			token := newRefreshToken(user, uuid.New())
+			token.TokenHash = refresh.TokenHash
+			return repository.NewRefreshTokenRepository(db).CreateRefreshToken(ctx, token)
+		}},
+		{"refresh token unknown user", func(db repository.DBTX) error {
+			return repository.NewRefreshTokenRepository(db).CreateRefreshToken(ctx, newRefreshToken(&model.User{ID: uuid.New()}, uuid.New()))
+		}},
+		{"refresh token rotation duplicate hash", func(db repository.DBTX) error {
+			next := newRefreshToken(user, refresh.FamilyID)
+			next.TokenHash = refresh.TokenHash
+			return repository.NewRefreshTokenRepository(db).RotateRefreshToken(ctx, refresh.ID, next)
+		}},
+		{"refresh token rotation reused", func(db repository.DBTX) error {
+			return repository.NewRefreshTokenRepository(db).RotateRefreshToken(ctx, uuid.New(), newRefreshToken(user, uuid.New()))
+		}},
+		{"revoked token unknown user", func(db repository.DBTX) error {
+			return repository.NewRevocationStore(db, 0).RevokeToken(ctx, "jti", uuid.NewString(), ts.Add(time.Hour))
+		}},
+		{"user token unknown purpose", func(db repository.DBTX) error {
+			return repository.NewUserTokenRepository(db).CreateUserToken(ctx, newUserToken(func(tok *model.UserToken) { tok.Purpose = "login" }))
+		}},
+		{"user token unknown user", func(db repository.DBTX) error {
+			return repository.NewUserTokenRepository(db).CreateUserToken(ctx, newUserToken(func(tok *model.UserToken) { tok.UserID = uuid.New() }))
+		}},
+		{"user token duplicate hash", func(db repository.DBTX) error {
+			repo := repository.NewUserTokenRepository(db)
+			tok := newUserToken(func(*model.UserToken) {})
+			if err := repo.CreateUserToken(ctx, tok); err != nil {
+				return err
+			}
+			return repo.CreateUserToken(ctx, newUserToken(func(next *model.UserToken) { next.TokenHash = tok.TokenHash }))
+		}},
+		{"user token consumed twice", func(db repository.DBTX) error {
+			repo := repository.NewUserTokenRepository(db)
+			tok := newUserToken(func(*model.UserToken) {})
+			if err := repo.CreateUserToken(ctx, tok); err != nil {
+				return err
+			}
+			if _, err := repo.ConsumeUserToken(ctx, tok.TokenHash, tok.Purpose); err != nil {
+				return err
+			}
+			_, err := repo.ConsumeUserToken(ctx, tok.TokenHash, tok.Purpose)
+			return err
+		}},
+	}
+
+	var got strings.Builder
+	for _, c := range cases {
+		err := inSavepoint(t, tx, c.fn)
+		fmt.Fprintf(&got, "%s: %s\n", c.name, describeError(err))
+	}
+
+	golden := filepath.Join("testdata", "constraints.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != string(want) {
+		t.Fatalf("constraint errors differ from %s (run with -update to accept):\n--- got\n%s--- want\n%s", golden, got.String(), want)
+	}
+}
+
+// describeError meringkas err menjadi bagian yang stabil antar versi Postgres: jenis error domain,
+// nama constraint, dan SQLSTATE. Pesan error Postgres sengaja tidak disertakan.
+func describeError(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	var dbErr *repository.DBError
+	if !errors.As(err, &dbErr) {
+		return "error=" + err.Error()
+	}
+	constraint, sqlstate := dbErr.Constraint, "-"
+	if constraint == "" {
+		constraint = "-"
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		sqlstate = pgErr.Code
+	}
+	return fmt.Sprintf("kind=%q constraint=%s sqlstate=%s", dbErr.Kind, constraint, sqlstate)
+}
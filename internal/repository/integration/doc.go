@@ -0,0 +1,17 @@
+// Package integration berisi test integrasi repository Postgres terhadap database sungguhan.
+// Test memastikan query di package repository cocok dengan skema hasil db/migrations.
+//
+// Test hanya dikompilasi dengan build tag integration:
+//
+//	go test -tags integration ./internal/repository/integration/
+//
+// Database yang dipakai:
+//   - TEST_DATABASE_URL jika di-set. Test membuat schema sementara, menjalankan migrasi di sana,
+//     lalu menghapusnya kembali, sehingga data lain di database tersebut tidak tersentuh.
+//   - Jika tidak, cluster Postgres sementara dijalankan dengan initdb dan pg_ctl dari PG_BIN,
+//     PATH, atau /usr/lib/postgresql/*/bin, lalu dihapus setelah test selesai. Jika binary
+//     tidak ditemukan (atau proses berjalan sebagai root), test di-skip.
+//
+// Setiap test berjalan di dalam transaksi yang di-rollback di akhir test. File golden di testdata
+// diperbarui dengan flag -update.
+package integration
@@ -0,0 +1,256 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"gochi-boilerplate/db/migrations"
+	"gochi-boilerplate/internal/migrate"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var update = flag.Bool("update", false, "tulis ulang file golden di testdata")
+
+var (
+	databaseURL string        // Database tempat schema test dibuat
+	pool        *pgxpool.Pool // Terhubung ke schema test yang sudah dimigrasi
+	skipReason  string        // Terisi jika tidak ada Postgres yang bisa dipakai
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx := context.Background()
+
+	databaseURL = os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		server, err := startPostgres()
+		if err != nil {
+			skipReason = "Postgres untuk test integrasi tidak tersedia: " + err.Error()
+			return m.Run()
+		}
+		defer server.stop()
+		databaseURL = server.url
+	}
+
+	p, cleanup, err := openSchema(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gagal menyiapkan schema test:", err)
+		return 1
+	}
+	defer cleanup()
+
+	migrator, err := migrate.New(p, migrations.FS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gagal membaca migrasi:", err)
+		return 1
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "gagal menjalankan migrasi:", err)
+		return 1
+	}
+
+	pool = p
+	return m.Run()
+}
+
+// openSchema membuat schema baru dengan nama acak di databaseURL dan mengembalikan pool yang
+// search_path-nya mengarah ke schema tersebut. cleanup menutup pool dan menghapus schema beserta isinya.
+func openSchema(ctx context.Context) (*pgxpool.Pool, func(), error) {
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	schema := "test_" + hex.EncodeToString(suffix)
+
+	admin, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer admin.Close(ctx)
+	// Ekstensi dipasang di public agar bisa dipakai semua schema test. Tanpa ini, CREATE EXTENSION IF NOT EXISTS
+	// di migrasi pertama akan memasangnya di schema test pertama saja dan schema berikutnya tidak menemukannya.
+	if _, err := admin.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS "uuid-ossp" SCHEMA public`); err != nil {
+		return nil, nil, err
+	}
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		return nil, nil, err
+	}
+	dropSchema := func() {
+		conn, err := pgx.Connect(context.Background(), databaseURL)
+		if err != nil {
+			return
+		}
+		defer conn.Close(context.Background())
+		conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	}
+
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		dropSchema()
+		return nil, nil, err
+	}
+	// public tetap di search_path untuk fungsi uuid_generate_v4
+	config.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	p, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		dropSchema()
+		return nil, nil, err
+	}
+	return p, func() { p.Close(); dropSchema() }, nil
+}
+
+// postgresServer adalah cluster Postgres sementara yang hanya menerima koneksi lewat unix socket
+type postgresServer struct {
+	dir   string
+	pgCtl string
+	url   string
+}
+
+func startPostgres() (*postgresServer, error) {
+	initdb, err := findPostgresBinary("initdb")
+	if err != nil {
+		return nil, err
+	}
+	pgCtl, err := findPostgresBinary("pg_ctl")
+	if err != nil {
+		return nil, err
+	}
+	if os.Geteuid() == 0 {
+		return nil, errors.New("initdb menolak berjalan sebagai root, set TEST_DATABASE_URL")
+	}
+
+	dir, err := os.MkdirTemp("", "gochi-pg-")
+	if err != nil {
+		return nil, err
+	}
+	s := &postgresServer{dir: dir, pgCtl: pgCtl}
+	data := filepath.Join(dir, "data")
+
+	out, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %w: %s", err, out)
+	}
+	// TCP dimatikan dan socket ditaruh di dir, sehingga tidak bentrok dengan Postgres lain di mesin yang sama
+	opts := fmt.Sprintf("-k %s -c listen_addresses='' -c fsync=off", dir)
+	out, err = exec.Command(pgCtl, "start", "-w", "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-o", opts).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pg_ctl start: %w: %s", err, out)
+	}
+
+	s.url = fmt.Sprintf("host=%s user=postgres dbname=postgres sslmode=disable", dir)
+	return s, nil
+}
+
+func (s *postgresServer) stop() {
+	exec.Command(s.pgCtl, "stop", "-D", filepath.Join(s.dir, "data"), "-m", "immediate").Run()
+	os.RemoveAll(s.dir)
+}
+
+// findPostgresBinary mencari binary Postgres di PG_BIN, PATH, lalu lokasi instalasi Debian/Ubuntu
+func findPostgresBinary(name string) (string, error) {
+	if dir := os.Getenv("PG_BIN"); dir != "" {
+		return exec.LookPath(filepath.Join(dir, name))
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	matches, _ := filepath.Glob("/usr/lib/postgresql/*/bin/" + name)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%s tidak ditemukan", name)
+	}
+	return matches[len(matches)-1], nil
+}
+
+// begin memulai transaksi yang otomatis di-rollback setelah test selesai
+func begin(t *testing.T) pgx.Tx {
+	t.Helper()
+	if pool == nil {
+		t.Skip(skipReason)
+	}
+	tx, err := pool.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tx.Rollback(context.Background()) })
+	return tx
+}
+
+// inSavepoint menjalankan fn di dalam savepoint yang selalu di-rollback. Dipakai untuk operasi yang
+// diharapkan gagal, karena error SQL membatalkan seluruh transaksi Postgres yang sedang berjalan.
+func inSavepoint(t *testing.T, tx pgx.Tx, fn func(db repository.DBTX) error) error {
+	t.Helper()
+	sp, err := tx.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.Rollback(context.Background())
+	return fn(sp)
+}
+
+// now mengembalikan waktu saat ini dengan presisi mikrodetik seperti TIMESTAMPTZ
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// createUser menyimpan pengguna baru; opts bisa mengubah field sebelum disimpan
+func createUser(t *testing.T, db repository.DBTX, opts ...func(u *model.User)) *model.User {
+	t.Helper()
+	ts := now()
+	id := uuid.New()
+	u := &model.User{
+		ID:        id,
+		FullName:  "Pengguna " + id.String()[:8],
+		Email:     id.String()[:8] + "@example.com",
+		Password:  "hash",
+		Role:      model.RoleUser,
+		CreatedAt: ts,
+		UpdatedAt: ts,
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	if err := repository.NewUserRepository(db).CreateUser(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// createProduct menyimpan produk baru milik owner (boleh nil) dengan created_at tertentu
+func createProduct(t *testing.T, db repository.DBTX, owner *model.User, name string, price int, createdAt time.Time) *model.Product {
+	t.Helper()
+	p := &model.Product{ID: uuid.New(), Name: name, Price: price, CreatedAt: createdAt, UpdatedAt: createdAt}
+	if owner != nil {
+		p.UserID = &owner.ID
+	}
+	if err := repository.NewProductRepository(db).CreateProduct(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// expectKind memastikan err adalah error domain kind
+func expectKind(t *testing.T, err, kind error) {
+	t.Helper()
+	if !errors.Is(err, kind) {
+		t.Fatalf("error = %v, want %v", err, kind)
+	}
+}
@@ -0,0 +1,87 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"gochi-boilerplate/db/migrations"
+	"gochi-boilerplate/internal/migrate"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestMigrationsRoundTrip menjalankan semua migrasi down lalu up lagi di schema tersendiri,
+// untuk memastikan setiap file down benar-benar membersihkan hasil file up-nya
+func TestMigrationsRoundTrip(t *testing.T) {
+	if pool == nil {
+		t.Skip(skipReason)
+	}
+	ctx := context.Background()
+	p, cleanup, err := openSchema(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	migrator, err := migrate.New(p, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Status hanya membaca: schema kosong berarti semua migrasi tertunda, dan tabelnya tidak dibuat
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses, _ := migrator.Status(ctx); pending == 0 || pending != len(statuses) {
+		t.Fatalf("pending = %d on an empty schema, want all %d", pending, len(statuses))
+	}
+	if left := schemaObjects(t, p); len(left) != 0 {
+		t.Fatalf("status created objects: %v", left)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := schemaObjects(t, p)
+
+	if _, err := migrator.Down(ctx, len(applied)); err != nil {
+		t.Fatal(err)
+	}
+	if left := schemaObjects(t, p); !slices.Equal(left, []string{"table schema_migrations"}) {
+		t.Fatalf("objects left after migrating down: %v", left)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrating up again: %v", err)
+	}
+	if got := schemaObjects(t, p); !slices.Equal(got, want) {
+		t.Fatalf("schema differs after round trip:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+// schemaObjects mendaftar tabel, tipe ENUM, dan index di schema aktif
+func schemaObjects(t *testing.T, p *pgxpool.Pool) []string {
+	t.Helper()
+	rows, err := p.Query(context.Background(), `
+		SELECT 'table ' || table_name FROM information_schema.tables WHERE table_schema = current_schema()
+		UNION ALL
+		SELECT 'type ' || t.typname FROM pg_type t JOIN pg_namespace n ON n.oid = t.typnamespace
+			WHERE n.nspname = current_schema() AND t.typtype = 'e'
+		UNION ALL
+		SELECT 'index ' || indexname FROM pg_indexes
+			WHERE schemaname = current_schema() AND tablename <> 'schema_migrations'
+		ORDER BY 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
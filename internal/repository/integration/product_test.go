@@ -0,0 +1,333 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestProductCRUD(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewProductRepository(tx)
+	owner := createUser(t, tx)
+	product := createProduct(t, tx, owner, "Laptop", 1000, now())
+
+	got, err := repo.GetProductByID(ctx, product.ID, model.ProductInclude{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Laptop" || got.Price != 1000 || *got.UserID != owner.ID || !got.CreatedAt.Equal(product.CreatedAt) || got.Owner != nil || got.Version != 1 {
+		t.Fatalf("unexpected product: %+v", got)
+	}
+
+	got, err = repo.GetProductByID(ctx, product.ID, model.ProductInclude{Owner: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Owner == nil || got.Owner.ID != owner.ID || got.Owner.FullName != owner.FullName {
+		t.Fatalf("unexpected owner: %+v", got.Owner)
+	}
+
+	stale := *product
+	product.Name, product.Price, product.UpdatedAt = "Laptop Baru", 1500, now()
+	if err := repo.UpdateProduct(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+	if product.Version != 2 {
+		t.Fatalf("version = %d, want 2", product.Version)
+	}
+	if got, _ = repo.GetProductByID(ctx, product.ID, model.ProductInclude{}); got.Name != "Laptop Baru" || got.Price != 1500 || got.Version != 2 {
+		t.Fatalf("update not persisted: %+v", got)
+	}
+
+	// Perubahan berdasarkan versi lama ditolak dan tidak menimpa apa pun
+	stale.Name = "Laptop Lama"
+	expectKind(t, repo.UpdateProduct(ctx, &stale), repository.ErrStale)
+	expectKind(t, repo.DeleteProduct(ctx, product.ID, stale.Version), repository.ErrStale)
+	if got, _ = repo.GetProductByID(ctx, product.ID, model.ProductInclude{}); got.Name != "Laptop Baru" {
+		t.Fatalf("stale update was persisted: %+v", got)
+	}
+
+	if err := repo.DeleteProduct(ctx, product.ID, product.Version); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.GetProductByID(ctx, product.ID, model.ProductInclude{})
+	expectKind(t, err, repository.ErrNotFound)
+	expectKind(t, repo.DeleteProduct(ctx, product.ID, product.Version), repository.ErrNotFound)
+	expectKind(t, repo.UpdateProduct(ctx, product), repository.ErrNotFound)
+}
+
+func TestProductTrash(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewProductRepository(tx)
+	owner := createUser(t, tx)
+	product := createProduct(t, tx, owner, "Laptop", 1000, now())
+	kept := createProduct(t, tx, owner, "Mouse", 50, now())
+
+	_, err := repo.GetTrashedProductByID(ctx, product.ID)
+	expectKind(t, err, repository.ErrNotFound)
+	if err := repo.DeleteProduct(ctx, product.ID, product.Version); err != nil {
+		t.Fatal(err)
+	}
+
+	trashed, err := repo.GetTrashedProductByID(ctx, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trashed.DeletedAt == nil {
+		t.Fatalf("deleted_at not set: %+v", trashed)
+	}
+	for _, trash := range []bool{false, true} {
+		list, err := repo.GetAllProducts(ctx, model.ProductFilter{OwnerID: &owner.ID, Trashed: trash}, model.PageParams{Limit: 10, Page: 1}, model.ProductInclude{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := kept.ID
+		if trash {
+			want = product.ID
+		}
+		if len(list) != 1 || list[0].ID != want {
+			t.Fatalf("trashed=%v: got %+v", trash, list)
+		}
+	}
+	if _, total, _ := repo.SearchProducts(ctx, "laptop", model.PageParams{Limit: 10, Page: 1}); total != 0 {
+		t.Fatalf("search returned %d trashed products", total)
+	}
+
+	restored, err := repo.RestoreProduct(ctx, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DeletedAt != nil || restored.Name != "Laptop" {
+		t.Fatalf("unexpected restored product: %+v", restored)
+	}
+	_, err = repo.RestoreProduct(ctx, product.ID)
+	expectKind(t, err, repository.ErrNotFound)
+
+	// Hapus permanen berlaku untuk produk aktif maupun yang ada di trash
+	if err := repo.PurgeProduct(ctx, product.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectKind(t, repo.PurgeProduct(ctx, product.ID), repository.ErrNotFound)
+}
+
+func TestPurgeTrashedProducts(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewProductRepository(tx)
+	old := createProduct(t, tx, nil, "Lama", 10, now())
+	recent := createProduct(t, tx, nil, "Baru", 10, now())
+	active := createProduct(t, tx, nil, "Aktif", 10, now())
+	for _, p := range []*model.Product{old, recent} {
+		if err := repo.DeleteProduct(ctx, p.ID, p.Version); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE products SET deleted_at = NOW() - INTERVAL '31 days' WHERE id = $1`, old.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := repo.PurgeTrashedProducts(ctx, now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged = %d, want 1", purged)
+	}
+	_, err = repo.GetTrashedProductByID(ctx, old.ID)
+	expectKind(t, err, repository.ErrNotFound)
+	if _, err := repo.GetTrashedProductByID(ctx, recent.ID); err != nil {
+		t.Fatalf("recently trashed product purged: %v", err)
+	}
+	if _, err := repo.GetProductByID(ctx, active.ID, model.ProductInclude{}); err != nil {
+		t.Fatalf("active product purged: %v", err)
+	}
+}
+
+func TestProductOwnerlessAfterUserDelete(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	owner := createUser(t, tx)
+	product := createProduct(t, tx, owner, "Mouse", 50, now())
+
+	if err := repository.NewUserRepository(tx).DeleteUser(ctx, owner.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repository.NewProductRepository(tx).GetProductByID(ctx, product.ID, model.ProductInclude{Owner: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UserID != nil || got.Owner != nil {
+		t.Fatalf("product still has an owner: %+v", got)
+	}
+}
+
+func TestProductListing(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewProductRepository(tx)
+	alice := createUser(t, tx)
+	bob := createUser(t, tx)
+
+	base := now().Add(-time.Hour)
+	products := []*model.Product{
+		createProduct(t, tx, alice, "Kabel 100%", 10, base),
+		createProduct(t, tx, alice, "Kabel_USB", 20, base.Add(time.Minute)),
+		createProduct(t, tx, bob, "Monitor", 300, base.Add(2*time.Minute)),
+		createProduct(t, tx, nil, "Keyboard", 40, base.Add(3*time.Minute)),
+	}
+	ids := func(list []model.Product) []uuid.UUID {
+		var out []uuid.UUID
+		for _, p := range list {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+	want := func(idx ...int) []uuid.UUID {
+		var out []uuid.UUID
+		for _, i := range idx {
+			out = append(out, products[i].ID)
+		}
+		return out
+	}
+	intPtr := func(v int) *int { return &v }
+	after := base.Add(30 * time.Second)
+
+	tests := []struct {
+		name   string
+		filter model.ProductFilter
+		sort   []model.SortField
+		want   []uuid.UUID
+	}{
+		{"default order is newest first", model.ProductFilter{}, nil, want(3, 2, 1, 0)},
+		{"price ascending", model.ProductFilter{}, []model.SortField{{Field: "price"}}, want(0, 1, 3, 2)},
+		{"unknown sort field is ignored", model.ProductFilter{}, []model.SortField{{Field: "search_vector"}}, want(3, 2, 1, 0)},
+		{"price range", model.ProductFilter{MinPrice: intPtr(20), MaxPrice: intPtr(40)}, []model.SortField{{Field: "price"}}, want(1, 3)},
+		{"owner", model.ProductFilter{OwnerID: &alice.ID}, []model.SortField{{Field: "created_at"}}, want(0, 1)},
+		{"query is case-insensitive", model.ProductFilter{Query: "kabel"}, []model.SortField{{Field: "name"}}, want(0, 1)},
+		{"query escapes percent", model.ProductFilter{Query: "100%"}, nil, want(0)},
+		{"query escapes underscore", model.ProductFilter{Query: "l_U"}, nil, want(1)},
+		{"created after", model.ProductFilter{CreatedAfter: &after}, []model.SortField{{Field: "created_at"}}, want(1, 2, 3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetAllProducts(ctx, tt.filter, model.PageParams{Limit: 10, Page: 1, Sort: tt.sort}, model.ProductInclude{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ids(got), tt.want) {
+				t.Fatalf("got %v, want %v", ids(got), tt.want)
+			}
+			total, err := repo.CountProducts(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != int64(len(tt.want)) {
+				t.Fatalf("count = %d, want %d", total, len(tt.want))
+			}
+		})
+	}
+
+	t.Run("offset page", func(t *testing.T) {
+		got, err := repo.GetAllProducts(ctx, model.ProductFilter{}, model.PageParams{Limit: 3, Page: 2}, model.ProductInclude{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(ids(got), want(0)) {
+			t.Fatalf("got %v", ids(got))
+		}
+	})
+
+	for _, desc := range []bool{false, true} {
+		t.Run(fmt.Sprintf("keyset desc=%v", desc), func(t *testing.T) {
+			page := model.PageParams{Limit: 2, Sort: []model.SortField{{Field: "created_at", Desc: desc}}}
+			var seen []uuid.UUID
+			for {
+				got, err := repo.GetAllProducts(ctx, model.ProductFilter{}, page, model.ProductInclude{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Mode keyset mengambil satu baris ekstra sebagai penanda halaman berikutnya
+				more := len(got) > page.Limit
+				if more {
+					got = got[:page.Limit]
+				}
+				seen = append(seen, ids(got)...)
+				if !more {
+					break
+				}
+				last := got[len(got)-1]
+				page.After = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+			}
+			expected := want(0, 1, 2, 3)
+			if desc {
+				expected = want(3, 2, 1, 0)
+			}
+			if !slices.Equal(seen, expected) {
+				t.Fatalf("got %v, want %v", seen, expected)
+			}
+		})
+	}
+}
+
+func TestProductSearch(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewProductRepository(tx)
+	base := now()
+	gaming := createProduct(t, tx, nil, "Laptop Gaming", 2000, base)
+	office := createProduct(t, tx, nil, "Laptop Kantor Ringan", 1000, base.Add(time.Second))
+	createProduct(t, tx, nil, "Mouse Gaming", 100, base.Add(2*time.Second))
+
+	results, total, err := repo.SearchProducts(ctx, "lap", model.PageParams{Limit: 10, Page: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("total = %d, results = %d, want 2", total, len(results))
+	}
+	for _, r := range results {
+		if r.ID != gaming.ID && r.ID != office.ID {
+			t.Fatalf("unexpected result %q", r.Name)
+		}
+		if !strings.Contains(r.Highlight, "<mark>Laptop</mark>") || r.Rank <= 0 {
+			t.Fatalf("unexpected highlight or rank: %+v", r)
+		}
+	}
+
+	// Semua kata harus cocok; karakter operator tsquery diabaikan
+	results, total, err = repo.SearchProducts(ctx, "gam & lap | !", model.PageParams{Limit: 10, Page: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || results[0].ID != gaming.ID {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	results, total, err = repo.SearchProducts(ctx, "&|!", model.PageParams{Limit: 10, Page: 1})
+	if err != nil || total != 0 || len(results) != 0 {
+		t.Fatalf("empty query: results=%v total=%d err=%v", results, total, err)
+	}
+
+	// Nama di-escape HTML sebelum kata yang cocok ditandai
+	createProduct(t, tx, nil, `<script>alert("x")</script> Keyboard`, 10, base.Add(3*time.Second))
+	results, _, err = repo.SearchProducts(ctx, "keyboard", model.PageParams{Limit: 10, Page: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <mark>Keyboard</mark>`
+	if len(results) != 1 || results[0].Highlight != want {
+		t.Fatalf("highlight = %+v, want %q", results, want)
+	}
+}
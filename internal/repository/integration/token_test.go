@@ -0,0 +1,214 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// createRefreshToken menyimpan refresh token aktif milik user di family familyID
+func createRefreshToken(t *testing.T, db repository.DBTX, user *model.User, familyID uuid.UUID) *model.RefreshToken {
+	t.Helper()
+	token := newRefreshToken(user, familyID)
+	if err := repository.NewRefreshTokenRepository(db).CreateRefreshToken(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newRefreshToken(user *model.User, familyID uuid.UUID) *model.RefreshToken {
+	id := uuid.New()
+	return &model.RefreshToken{
+		ID:        id,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: "hash-" + id.String(),
+		ExpiresAt: now().Add(time.Hour),
+		CreatedAt: now(),
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewRefreshTokenRepository(tx)
+	user := createUser(t, tx)
+	first := createRefreshToken(t, tx, user, uuid.New())
+
+	got, err := repo.GetRefreshTokenByHash(ctx, first.TokenHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != first.ID || got.FamilyID != first.FamilyID || got.RevokedAt != nil || got.ReplacedBy != nil || !got.ExpiresAt.Equal(first.ExpiresAt) {
+		t.Fatalf("unexpected token: %+v", got)
+	}
+
+	second := newRefreshToken(user, first.FamilyID)
+	if err := repo.RotateRefreshToken(ctx, first.ID, second); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = repo.GetRefreshTokenByHash(ctx, first.TokenHash)
+	if got.RevokedAt == nil || got.ReplacedBy == nil || *got.ReplacedBy != second.ID {
+		t.Fatalf("old token not revoked: %+v", got)
+	}
+
+	// Rotasi kedua dari token yang sama ditolak dan tidak menyimpan apa pun
+	third := newRefreshToken(user, first.FamilyID)
+	if err := repo.RotateRefreshToken(ctx, first.ID, third); !errors.Is(err, repository.ErrRefreshTokenReused) {
+		t.Fatalf("error = %v, want ErrRefreshTokenReused", err)
+	}
+	_, err = repo.GetRefreshTokenByHash(ctx, third.TokenHash)
+	expectKind(t, err, repository.ErrNotFound)
+
+	if err := repo.RevokeFamily(ctx, first.FamilyID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = repo.GetRefreshTokenByHash(ctx, second.TokenHash); got.RevokedAt == nil {
+		t.Fatal("family not revoked")
+	}
+}
+
+func TestRefreshTokenRevokeAllForUser(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewRefreshTokenRepository(tx)
+	user := createUser(t, tx)
+	other := createUser(t, tx)
+	tokens := []*model.RefreshToken{
+		createRefreshToken(t, tx, user, uuid.New()),
+		createRefreshToken(t, tx, user, uuid.New()),
+	}
+	untouched := createRefreshToken(t, tx, other, uuid.New())
+
+	if err := repo.RevokeAllForUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range tokens {
+		if got, _ := repo.GetRefreshTokenByHash(ctx, token.TokenHash); got.RevokedAt == nil {
+			t.Fatalf("token %s not revoked", token.ID)
+		}
+	}
+	if got, _ := repo.GetRefreshTokenByHash(ctx, untouched.TokenHash); got.RevokedAt != nil {
+		t.Fatal("another user's token was revoked")
+	}
+}
+
+func TestAccessTokenRevocation(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	user := createUser(t, tx)
+	userID := user.ID.String()
+	issuedAt := now().Add(-time.Minute)
+
+	if err := repository.NewRevocationStore(tx, time.Minute).RevokeToken(ctx, "jti-1", userID, now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// Token yang sudah kedaluwarsa dibersihkan saat pencabutan berikutnya
+	if _, err := tx.Exec(ctx, `INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ('jti-lama', $1, $2)`,
+		user.ID, now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.NewRevocationStore(tx, time.Minute).RevokeToken(ctx, "jti-1", userID, now().Add(time.Hour)); err != nil {
+		t.Fatalf("revoking twice: %v", err)
+	}
+	var expired int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM revoked_tokens WHERE jti = 'jti-lama'`).Scan(&expired); err != nil {
+		t.Fatal(err)
+	}
+	if expired != 0 {
+		t.Fatal("expired revocation was not purged")
+	}
+
+	// Store baru tanpa cache memastikan hasilnya dibaca dari database
+	store := repository.NewRevocationStore(tx, 0)
+	check := func(jti string, issuedAt time.Time, want bool) {
+		t.Helper()
+		revoked, err := store.IsRevoked(ctx, jti, userID, issuedAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if revoked != want {
+			t.Fatalf("IsRevoked(%q, %v) = %v, want %v", jti, issuedAt, revoked, want)
+		}
+	}
+	check("jti-1", issuedAt, true)
+	check("jti-2", issuedAt, false)
+
+	cutoff := now()
+	if err := store.RevokeAllForUserBefore(ctx, userID, cutoff.Add(-time.Hour), "jti-2"); err != nil {
+		t.Fatal(err)
+	}
+	// Batas yang lebih baru menggantikan batas dan pengecualian sebelumnya (upsert)
+	if err := store.RevokeAllForUserBefore(ctx, userID, cutoff, "jti-3"); err != nil {
+		t.Fatal(err)
+	}
+	store = repository.NewRevocationStore(tx, 0)
+	check("jti-2", issuedAt, true)
+	check("jti-2", cutoff, false)
+	check("", cutoff.Add(time.Second), false)
+	check("jti-3", issuedAt, false)
+	check("", issuedAt, true)
+}
+
+func TestUserTokens(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewUserTokenRepository(tx)
+	user := createUser(t, tx)
+	newToken := func(purpose string, expiresAt time.Time) *model.UserToken {
+		t.Helper()
+		token := &model.UserToken{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Purpose:   purpose,
+			TokenHash: "hash-" + uuid.NewString(),
+			Email:     user.Email,
+			ExpiresAt: expiresAt,
+			CreatedAt: now(),
+		}
+		if err := repo.CreateUserToken(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	first := newToken(model.TokenPurposeVerifyEmail, now().Add(time.Hour))
+	reset := newToken(model.TokenPurposeResetPassword, now().Add(time.Hour))
+	second := newToken(model.TokenPurposeVerifyEmail, now().Add(time.Hour))
+
+	// Token baru membatalkan token lama dengan tujuan yang sama saja
+	_, err := repo.ConsumeUserToken(ctx, first.TokenHash, model.TokenPurposeVerifyEmail)
+	expectKind(t, err, repository.ErrNotFound)
+	_, err = repo.ConsumeUserToken(ctx, second.TokenHash, model.TokenPurposeResetPassword)
+	expectKind(t, err, repository.ErrNotFound)
+
+	got, err := repo.ConsumeUserToken(ctx, second.TokenHash, model.TokenPurposeVerifyEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != second.ID || got.Email != user.Email || got.UsedAt == nil {
+		t.Fatalf("unexpected token: %+v", got)
+	}
+	_, err = repo.ConsumeUserToken(ctx, second.TokenHash, model.TokenPurposeVerifyEmail)
+	expectKind(t, err, repository.ErrNotFound)
+
+	if _, err := repo.ConsumeUserToken(ctx, reset.TokenHash, model.TokenPurposeResetPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := newToken(model.TokenPurposeResetPassword, now().Add(-time.Minute))
+	_, err = repo.ConsumeUserToken(ctx, expired.TokenHash, model.TokenPurposeResetPassword)
+	expectKind(t, err, repository.ErrNotFound)
+
+	change := newToken(model.TokenPurposeChangeEmail, now().Add(time.Hour))
+	if _, err := repo.ConsumeUserToken(ctx, change.TokenHash, model.TokenPurposeChangeEmail); err != nil {
+		t.Fatal(err)
+	}
+}
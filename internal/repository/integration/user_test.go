@@ -0,0 +1,307 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestUserCRUD(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewUserRepository(tx)
+	user := createUser(t, tx, func(u *model.User) { u.Role = model.RoleEditor })
+
+	byEmail, err := repo.GetUserByEmail(ctx, user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID, err := repo.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, got := range []*model.User{byEmail, byID} {
+		if got.ID != user.ID || got.Email != user.Email || got.Role != model.RoleEditor || got.Password != "hash" ||
+			got.EmailVerifiedAt != nil || got.FailedLoginAttempts != 0 || got.LockedUntil != nil ||
+			got.DisabledAt != nil || got.PasswordResetRequired || !got.CreatedAt.Equal(user.CreatedAt) {
+			t.Fatalf("unexpected user: %+v", got)
+		}
+	}
+
+	// Pencarian email bersifat case-sensitive, sama seperti constraint UNIQUE-nya
+	_, err = repo.GetUserByEmail(ctx, "X"+user.Email)
+	expectKind(t, err, repository.ErrNotFound)
+	_, err = repo.GetUserByID(ctx, uuid.New())
+	expectKind(t, err, repository.ErrNotFound)
+
+	verifiedAt := now()
+	user.FullName, user.Email, user.EmailVerifiedAt, user.UpdatedAt = "Nama Baru", "baru-"+user.Email, &verifiedAt, now()
+	if err := repo.UpdateProfile(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := repo.GetUserByID(ctx, user.ID)
+	if got.FullName != "Nama Baru" || got.Email != user.Email || got.EmailVerifiedAt == nil || !got.EmailVerifiedAt.Equal(verifiedAt) {
+		t.Fatalf("profile not updated: %+v", got)
+	}
+	expectKind(t, repo.UpdateProfile(ctx, &model.User{ID: uuid.New(), Email: "lain@example.com"}), repository.ErrNotFound)
+}
+
+func TestUserEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewUserRepository(tx)
+	user := createUser(t, tx)
+
+	// Token untuk alamat lama tidak berlaku setelah email diganti
+	expectKind(t, repo.MarkEmailVerified(ctx, user.ID, "lama@example.com"), repository.ErrNotFound)
+	if err := repo.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.GetUserByID(ctx, user.ID); got.EmailVerifiedAt == nil {
+		t.Fatal("email_verified_at not set")
+	}
+}
+
+func TestUserLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewUserRepository(tx)
+	user := createUser(t, tx)
+	policy := model.LockoutPolicy{Threshold: 3, Base: time.Minute, Max: 3 * time.Minute}
+
+	var locks []*time.Time
+	for range 5 {
+		lockedUntil, err := repo.RecordLoginFailure(ctx, user.ID, policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		locks = append(locks, lockedUntil)
+	}
+	if locks[0] != nil || locks[1] != nil {
+		t.Fatalf("locked before threshold: %v", locks[:2])
+	}
+	// Lama penguncian berlipat dua (1, 2 menit) lalu dibatasi policy.Max (3 menit), dihitung dari NOW() transaksi
+	started := locks[2].Add(-time.Minute)
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		if got := locks[i+2].Sub(started); got != want {
+			t.Fatalf("lock %d lasts %v, want %v", i+3, got, want)
+		}
+	}
+
+	got, _ := repo.GetUserByID(ctx, user.ID)
+	if got.FailedLoginAttempts != 5 || got.LockedUntil == nil {
+		t.Fatalf("unexpected lockout state: %+v", got)
+	}
+
+	if err := repo.ResetLoginFailures(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = repo.GetUserByID(ctx, user.ID); got.FailedLoginAttempts != 0 || got.LockedUntil != nil {
+		t.Fatalf("lockout not reset: %+v", got)
+	}
+	if err := repo.ResetLoginFailures(ctx, uuid.New()); err != nil {
+		t.Fatalf("reset for unknown user: %v", err)
+	}
+	_, err := repo.RecordLoginFailure(ctx, uuid.New(), policy)
+	expectKind(t, err, repository.ErrNotFound)
+}
+
+func TestUserUpdatePassword(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewUserRepository(tx)
+	user := createUser(t, tx)
+	admin := createUser(t, tx, func(u *model.User) { u.Role = model.RoleAdmin })
+
+	if err := repo.RequirePasswordReset(ctx, user.ID, &model.AuditEntry{ActorID: admin.ID, Action: model.AdminActionForcePasswordReset, TargetUserID: user.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.RecordLoginFailure(ctx, user.ID, model.LockoutPolicy{Threshold: 1, Base: time.Minute, Max: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.UpdatePassword(ctx, user.ID, "hash-baru"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := repo.GetUserByID(ctx, user.ID)
+	if got.Password != "hash-baru" || got.PasswordResetRequired || got.FailedLoginAttempts != 0 || got.LockedUntil != nil {
+		t.Fatalf("unexpected state after password change: %+v", got)
+	}
+	expectKind(t, repo.UpdatePassword(ctx, uuid.New(), "hash"), repository.ErrNotFound)
+}
+
+func TestUserDelete(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewUserRepository(tx)
+	revocations := repository.NewRevocationStore(tx, 0)
+	user := createUser(t, tx)
+	refresh := createRefreshToken(t, tx, user, uuid.New())
+	if err := revocations.RevokeToken(ctx, "jti-1", user.ID.String(), now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	cutoff := now()
+	if err := revocations.RevokeAllForUserBefore(ctx, user.ID.String(), cutoff, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectKind(t, repo.DeleteUser(ctx, user.ID), repository.ErrNotFound)
+
+	_, err := repository.NewRefreshTokenRepository(tx).GetRefreshTokenByHash(ctx, refresh.TokenHash)
+	expectKind(t, err, repository.ErrNotFound)
+
+	// Batas logout-all tetap ada (migrasi 000007), jadi access token lama tetap ditolak;
+	// store baru dipakai agar hasilnya dibaca dari database, bukan cache
+	revoked, err := repository.NewRevocationStore(tx, 0).IsRevoked(ctx, "", user.ID.String(), cutoff.Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Fatal("logout-all cutoff was deleted with the user")
+	}
+}
+
+func TestUserAdminListing(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewUserRepository(tx)
+
+	base := now().Add(-time.Hour)
+	users := []*model.User{
+		createUser(t, tx, func(u *model.User) { u.FullName, u.Email, u.CreatedAt = "Andi", "andi@example.com", base }),
+		createUser(t, tx, func(u *model.User) {
+			u.FullName, u.Email, u.Role, u.CreatedAt = "Budi", "budi@example.com", model.RoleAdmin, base.Add(time.Minute)
+		}),
+		createUser(t, tx, func(u *model.User) {
+			u.FullName, u.Email, u.CreatedAt = "Citra", "citra@example.com", base.Add(2*time.Minute)
+		}),
+	}
+	admin := users[1]
+	if err := repo.SetDisabled(ctx, users[2].ID, true, &model.AuditEntry{ActorID: admin.ID, Action: model.AdminActionDisable, TargetUserID: users[2].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		filter model.UserFilter
+		sort   []model.SortField
+		want   []int
+	}{
+		{"all newest first", model.UserFilter{}, nil, []int{2, 1, 0}},
+		{"query matches name case-insensitively", model.UserFilter{Query: "BUDI"}, nil, []int{1}},
+		{"query matches email", model.UserFilter{Query: "citra@"}, nil, []int{2}},
+		{"role", model.UserFilter{Role: model.RoleAdmin}, nil, []int{1}},
+		{"disabled", model.UserFilter{Disabled: &yes}, nil, []int{2}},
+		{"active sorted by name", model.UserFilter{Disabled: &no}, []model.SortField{{Field: "full_name"}}, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.ListUsers(ctx, tt.filter, model.PageParams{Limit: 10, Page: 1, Sort: tt.sort})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotIDs, wantIDs []uuid.UUID
+			for _, u := range got {
+				gotIDs = append(gotIDs, u.ID)
+			}
+			for _, i := range tt.want {
+				wantIDs = append(wantIDs, users[i].ID)
+			}
+			if !slices.Equal(gotIDs, wantIDs) {
+				t.Fatalf("got %v, want %v", gotIDs, wantIDs)
+			}
+			total, err := repo.CountUsers(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != int64(len(tt.want)) {
+				t.Fatalf("count = %d, want %d", total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestUserAdminActionsAreAudited(t *testing.T) {
+	ctx := context.Background()
+	tx := begin(t)
+	repo := repository.NewUserRepository(tx)
+	admin := createUser(t, tx, func(u *model.User) { u.Role = model.RoleAdmin })
+	user := createUser(t, tx)
+	audit := func(action model.AdminAction, details map[string]any) *model.AuditEntry {
+		return &model.AuditEntry{ActorID: admin.ID, Action: action, TargetUserID: user.ID, Details: details}
+	}
+
+	if err := repo.UpdateRole(ctx, user.ID, model.RoleEditor, audit(model.AdminActionChangeRole, map[string]any{"from": "user", "to": "editor"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetDisabled(ctx, user.ID, true, audit(model.AdminActionDisable, nil)); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := repo.GetUserByID(ctx, user.ID)
+	if got.Role != model.RoleEditor || got.DisabledAt == nil {
+		t.Fatalf("unexpected user: %+v", got)
+	}
+	disabledAt := *got.DisabledAt
+
+	// Menonaktifkan ulang tidak mengubah waktu nonaktif pertama
+	if err := repo.SetDisabled(ctx, user.ID, true, audit(model.AdminActionDisable, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = repo.GetUserByID(ctx, user.ID); !got.DisabledAt.Equal(disabledAt) {
+		t.Fatalf("disabled_at changed from %v to %v", disabledAt, got.DisabledAt)
+	}
+
+	if err := repo.SetDisabled(ctx, user.ID, false, audit(model.AdminActionEnable, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.RecordAudit(ctx, audit(model.AdminActionImpersonate, map[string]any{"expires_at": "nanti"})); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = repo.GetUserByID(ctx, user.ID); got.DisabledAt != nil {
+		t.Fatalf("user still disabled: %+v", got)
+	}
+
+	// Perubahan pada pengguna yang tidak ada tidak boleh meninggalkan audit entry
+	expectKind(t, repo.UpdateRole(ctx, uuid.New(), model.RoleUser, audit(model.AdminActionChangeRole, nil)), repository.ErrNotFound)
+
+	rows, err := tx.Query(ctx, `SELECT action, details->>'to' FROM admin_audit_logs WHERE actor_id = $1 ORDER BY created_at, id`, admin.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var actions []model.AdminAction
+	var to *string
+	for rows.Next() {
+		var action string
+		var detail *string
+		if err := rows.Scan(&action, &detail); err != nil {
+			t.Fatal(err)
+		}
+		if model.AdminAction(action) == model.AdminActionChangeRole {
+			to = detail
+		}
+		actions = append(actions, model.AdminAction(action))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.AdminAction{model.AdminActionChangeRole, model.AdminActionDisable, model.AdminActionDisable, model.AdminActionEnable, model.AdminActionImpersonate}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("audit actions = %v, want %v", actions, want)
+	}
+	if to == nil || *to != model.RoleEditor {
+		t.Fatalf("change_role details not stored: %v", to)
+	}
+}
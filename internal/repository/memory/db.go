@@ -0,0 +1,146 @@
+// Package memory berisi implementasi in-memory dari interface penyimpanan di package repository.
+// Dipakai untuk test handler tanpa Postgres. Semantiknya mengikuti skema database: email unik,
+// CHECK harga, foreign key beserta ON DELETE CASCADE / SET NULL, dan error domain yang sama.
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DB adalah "database" bersama untuk semua repository in-memory, sehingga relasi antar tabel
+// (misal produk yang pemiliknya dihapus) berperilaku seperti di Postgres. Aman dipakai bersamaan.
+type DB struct {
+	mu sync.Mutex
+
+	users         map[uuid.UUID]model.User
+	products      map[uuid.UUID]model.Product
+	refreshTokens map[uuid.UUID]model.RefreshToken
+	revokedTokens map[string]revokedToken // jti -> token yang dicabut
+	cutoffs       map[string]userCutoff   // user_id -> batas logout-all
+	userTokens    map[uuid.UUID]model.UserToken
+	audit         []model.AuditEntry
+}
+
+type revokedToken struct {
+	userID    string
+	expiresAt time.Time
+}
+
+type userCutoff struct {
+	before    time.Time
+	exemptJTI string
+}
+
+func NewDB() *DB {
+	return &DB{
+		users:         make(map[uuid.UUID]model.User),
+		products:      make(map[uuid.UUID]model.Product),
+		refreshTokens: make(map[uuid.UUID]model.RefreshToken),
+		revokedTokens: make(map[string]revokedToken),
+		cutoffs:       make(map[string]userCutoff),
+		userTokens:    make(map[uuid.UUID]model.UserToken),
+	}
+}
+
+// AuditEntries mengembalikan salinan seluruh audit log, untuk diperiksa di test
+func (db *DB) AuditEntries() []model.AuditEntry {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return slices.Clone(db.audit)
+}
+
+// Error dibuat dengan bentuk yang sama seperti hasil translateError pada repository Postgres
+
+func errNotFound() error {
+	return &repository.DBError{Kind: repository.ErrNotFound, Err: pgx.ErrNoRows}
+}
+
+func errConflict(constraint string) error {
+	return &repository.DBError{Kind: repository.ErrConflict, Constraint: constraint, Err: errors.New("duplicate key value violates unique constraint")}
+}
+
+func errConstraint(constraint string) error {
+	return &repository.DBError{Kind: repository.ErrConstraint, Constraint: constraint, Err: errors.New("constraint violated")}
+}
+
+func errStale() error {
+	return &repository.DBError{Kind: repository.ErrStale, Err: errors.New("modified concurrently")}
+}
+
+// compareFunc membandingkan dua item pada satu kolom, mengembalikan -1, 0, atau 1
+type compareFunc[T any] func(a, b T) int
+
+// paginate menerapkan cursor keyset, ORDER BY, LIMIT, dan OFFSET pada items seperti query daftar
+// di repository Postgres: kolom sort yang tidak ada di columns diabaikan, default created_at DESC,
+// id sebagai penentu urutan terakhir, dan mode keyset mengambil satu item ekstra (Limit+1).
+func paginate[T any](items []T, page model.PageParams, columns map[string]compareFunc[T], keyOf func(T) model.Cursor) []T {
+	if page.After != nil {
+		desc := len(page.Sort) > 0 && page.Sort[0].Desc
+		items = slices.DeleteFunc(items, func(item T) bool {
+			c := compareCursor(keyOf(item), *page.After)
+			if desc {
+				return c >= 0
+			}
+			return c <= 0
+		})
+	}
+
+	type orderBy struct {
+		compare compareFunc[T]
+		desc    bool
+	}
+	var order []orderBy
+	idDesc := true
+	for _, s := range page.Sort {
+		if compare, ok := columns[s.Field]; ok {
+			order = append(order, orderBy{compare, s.Desc})
+			idDesc = s.Desc
+		}
+	}
+	if len(order) == 0 {
+		order = append(order, orderBy{func(a, b T) int { return keyOf(a).CreatedAt.Compare(keyOf(b).CreatedAt) }, true})
+	}
+	order = append(order, orderBy{func(a, b T) int { return compareUUID(keyOf(a).ID, keyOf(b).ID) }, idDesc})
+
+	slices.SortFunc(items, func(a, b T) int {
+		for _, o := range order {
+			c := o.compare(a, b)
+			if o.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	limit := page.Limit
+	if !page.OffsetMode() {
+		limit++
+	}
+	offset := min(page.Offset(), len(items))
+	return items[offset:min(offset+limit, len(items))]
+}
+
+// compareCursor membandingkan pasangan (created_at, id) seperti perbandingan row value di Postgres
+func compareCursor(a, b model.Cursor) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return compareUUID(a.ID, b.ID)
+}
+
+// compareUUID mengikuti urutan uuid di Postgres (perbandingan byte)
+func compareUUID(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
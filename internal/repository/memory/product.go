@@ -0,0 +1,326 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"html"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+type ProductRepository struct {
+	db *DB
+}
+
+func NewProductRepository(db *DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+var _ repository.ProductStore = (*ProductRepository)(nil)
+
+func (r *ProductRepository) CreateProduct(ctx context.Context, product *model.Product) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.db.checkProduct(product); err != nil {
+		return err
+	}
+	if _, ok := r.db.products[product.ID]; ok {
+		return errConflict("products_pkey")
+	}
+	product.Version = 1
+	stored := *product
+	stored.Owner = nil
+	r.db.products[product.ID] = stored
+	return nil
+}
+
+// checkProduct meniru CHECK (price >= 0) dan foreign key fk_user pada tabel products
+func (db *DB) checkProduct(p *model.Product) error {
+	if p.Price < 0 {
+		return errConstraint("products_price_check")
+	}
+	if p.UserID != nil {
+		if _, ok := db.users[*p.UserID]; !ok {
+			return errConstraint("fk_user")
+		}
+	}
+	return nil
+}
+
+// withOwner menyalin produk dan mengisi data pemilik jika diminta, seperti LEFT JOIN pada selectProducts
+func (db *DB) withOwner(p model.Product, include model.ProductInclude) model.Product {
+	if include.Owner && p.UserID != nil {
+		if u, ok := db.users[*p.UserID]; ok {
+			p.Owner = &model.UserSummary{ID: u.ID, FullName: u.FullName}
+		}
+	}
+	return p
+}
+
+// productSortColumns adalah padanan productSortColumns pada repository Postgres
+var productSortColumns = map[string]compareFunc[model.Product]{
+	"name":       func(a, b model.Product) int { return cmp.Compare(a.Name, b.Name) },
+	"price":      func(a, b model.Product) int { return cmp.Compare(a.Price, b.Price) },
+	"created_at": func(a, b model.Product) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b model.Product) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+	"deleted_at": func(a, b model.Product) int { return compareTimePtr(a.DeletedAt, b.DeletedAt) },
+}
+
+// compareTimePtr mengurutkan nil di belakang, seperti NULL pada ORDER BY ASC di Postgres
+func compareTimePtr(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+func (r *ProductRepository) GetAllProducts(ctx context.Context, filter model.ProductFilter, page model.PageParams, include model.ProductInclude) ([]model.Product, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	products := []model.Product{}
+	for _, p := range r.db.products {
+		if matchProduct(p, filter) {
+			products = append(products, r.db.withOwner(p, include))
+		}
+	}
+	return paginate(products, page, productSortColumns, func(p model.Product) model.Cursor {
+		return model.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+	}), nil
+}
+
+func (r *ProductRepository) CountProducts(ctx context.Context, filter model.ProductFilter) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var total int64
+	for _, p := range r.db.products {
+		if matchProduct(p, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// matchProduct adalah padanan buildProductWhere pada repository Postgres
+func matchProduct(p model.Product, filter model.ProductFilter) bool {
+	if (p.DeletedAt != nil) != filter.Trashed {
+		return false
+	}
+	switch {
+	case filter.MinPrice != nil && p.Price < *filter.MinPrice,
+		filter.MaxPrice != nil && p.Price > *filter.MaxPrice,
+		filter.OwnerID != nil && (p.UserID == nil || *p.UserID != *filter.OwnerID),
+		filter.Query != "" && !containsFold(p.Name, filter.Query),
+		filter.CreatedAfter != nil && !p.CreatedAt.After(*filter.CreatedAfter):
+		return false
+	}
+	return true
+}
+
+// SearchProducts meniru pencarian full-text dengan prefix matching: setiap kata pencarian harus menjadi
+// awalan salah satu kata di nama produk. Skor relevansi hanya perkiraan (proporsi kata yang cocok),
+// bukan ts_rank, tetapi urutannya tetap skor menurun lalu created_at dan id menurun.
+func (r *ProductRepository) SearchProducts(ctx context.Context, text string, page model.PageParams) ([]model.ProductSearchResult, int64, error) {
+	results := []model.ProductSearchResult{}
+	terms := splitWords(strings.ToLower(text))
+	if len(terms) == 0 {
+		return results, 0, nil
+	}
+
+	r.db.mu.Lock()
+	for _, p := range r.db.products {
+		if p.DeletedAt != nil {
+			continue
+		}
+		words := splitWords(strings.ToLower(p.Name))
+		matched := 0
+		for _, w := range words {
+			if matchesAnyPrefix(w, terms) {
+				matched++
+			}
+		}
+		if !allTermsMatch(terms, words) {
+			continue
+		}
+		results = append(results, model.ProductSearchResult{
+			Product:   p,
+			Rank:      float32(matched) / float32(len(words)),
+			Highlight: highlight(p.Name, terms),
+		})
+	}
+	r.db.mu.Unlock()
+
+	slices.SortFunc(results, func(a, b model.ProductSearchResult) int {
+		return cmp.Or(
+			cmp.Compare(b.Rank, a.Rank),
+			b.CreatedAt.Compare(a.CreatedAt),
+			compareUUID(b.ID, a.ID),
+		)
+	})
+
+	total := int64(len(results))
+	offset := min(page.Offset(), len(results))
+	return results[offset:min(offset+page.Limit, len(results))], total, nil
+}
+
+// splitWords memecah teks menjadi kata berupa huruf dan angka, seperti buildPrefixTSQuery
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func matchesAnyPrefix(word string, terms []string) bool {
+	return slices.ContainsFunc(terms, func(t string) bool { return strings.HasPrefix(word, t) })
+}
+
+func allTermsMatch(terms, words []string) bool {
+	for _, t := range terms {
+		if !slices.ContainsFunc(words, func(w string) bool { return strings.HasPrefix(w, t) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// highlight meng-escape nama sebagai HTML lalu menandai kata yang cocok dengan <mark>, seperti
+// ts_headline dengan HighlightAll pada versi Postgres
+func highlight(name string, terms []string) string {
+	var b strings.Builder
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		if matchesAnyPrefix(strings.ToLower(string(word)), terms) {
+			b.WriteString("<mark>" + html.EscapeString(string(word)) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(string(word)))
+		}
+		word = word[:0]
+	}
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteString(html.EscapeString(string(r)))
+	}
+	flush()
+	return b.String()
+}
+
+func (r *ProductRepository) GetProductByID(ctx context.Context, id uuid.UUID, include model.ProductInclude) (*model.Product, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	p, ok := r.db.products[id]
+	if !ok || p.DeletedAt != nil {
+		return nil, errNotFound()
+	}
+	p = r.db.withOwner(p, include)
+	return &p, nil
+}
+
+func (r *ProductRepository) GetTrashedProductByID(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	p, ok := r.db.products[id]
+	if !ok || p.DeletedAt == nil {
+		return nil, errNotFound()
+	}
+	return &p, nil
+}
+
+func (r *ProductRepository) UpdateProduct(ctx context.Context, product *model.Product) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	p, ok := r.db.products[product.ID]
+	if !ok || p.DeletedAt != nil {
+		return errNotFound()
+	}
+	if p.Version != product.Version {
+		return errStale()
+	}
+	if product.Price < 0 {
+		return errConstraint("products_price_check")
+	}
+	p.Name, p.Price, p.UpdatedAt = product.Name, product.Price, product.UpdatedAt
+	p.Version++
+	product.Version = p.Version
+	r.db.products[product.ID] = p
+	return nil
+}
+
+func (r *ProductRepository) DeleteProduct(ctx context.Context, id uuid.UUID, version int) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	p, ok := r.db.products[id]
+	if !ok || p.DeletedAt != nil {
+		return errNotFound()
+	}
+	if p.Version != version {
+		return errStale()
+	}
+	now := time.Now()
+	p.DeletedAt = &now
+	p.Version++
+	r.db.products[id] = p
+	return nil
+}
+
+func (r *ProductRepository) RestoreProduct(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	p, ok := r.db.products[id]
+	if !ok || p.DeletedAt == nil {
+		return nil, errNotFound()
+	}
+	p.DeletedAt, p.UpdatedAt = nil, time.Now()
+	p.Version++
+	r.db.products[id] = p
+	return &p, nil
+}
+
+func (r *ProductRepository) PurgeProduct(ctx context.Context, id uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.products[id]; !ok {
+		return errNotFound()
+	}
+	delete(r.db.products, id)
+	return nil
+}
+
+func (r *ProductRepository) PurgeTrashedProducts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var purged int64
+	for id, p := range r.db.products {
+		if p.DeletedAt != nil && p.DeletedAt.Before(deletedBefore) {
+			delete(r.db.products, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepository struct {
+	db *DB
+}
+
+func NewRefreshTokenRepository(db *DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+var _ repository.RefreshTokenStore = (*RefreshTokenRepository)(nil)
+
+func (r *RefreshTokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return r.db.insertRefreshToken(token)
+}
+
+// insertRefreshToken meniru constraint tabel refresh_tokens; lock harus sudah dipegang
+func (db *DB) insertRefreshToken(token *model.RefreshToken) error {
+	if _, ok := db.users[token.UserID]; !ok {
+		return errConstraint("refresh_tokens_user_id_fkey")
+	}
+	if _, ok := db.refreshTokens[token.ID]; ok {
+		return errConflict("refresh_tokens_pkey")
+	}
+	for _, t := range db.refreshTokens {
+		if t.TokenHash == token.TokenHash {
+			return errConflict("refresh_tokens_token_hash_key")
+		}
+	}
+	db.refreshTokens[token.ID] = *token
+	return nil
+}
+
+func (r *RefreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, t := range r.db.refreshTokens {
+		if t.TokenHash == hash {
+			return &t, nil
+		}
+	}
+	return nil, errNotFound()
+}
+
+func (r *RefreshTokenRepository) RotateRefreshToken(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	old, ok := r.db.refreshTokens[oldID]
+	if !ok || old.RevokedAt != nil {
+		return repository.ErrRefreshTokenReused
+	}
+	if err := r.db.insertRefreshToken(next); err != nil {
+		return err
+	}
+	now := time.Now()
+	old.RevokedAt, old.ReplacedBy = &now, &next.ID
+	r.db.refreshTokens[oldID] = old
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.revokeWhere(func(t model.RefreshToken) bool { return t.FamilyID == familyID })
+	return nil
+}
+
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	r.revokeWhere(func(t model.RefreshToken) bool { return t.UserID == userID })
+	return nil
+}
+
+// revokeWhere mencabut semua token aktif yang cocok dengan match
+func (r *RefreshTokenRepository) revokeWhere(match func(model.RefreshToken) bool) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	for id, t := range r.db.refreshTokens {
+		if t.RevokedAt == nil && match(t) {
+			t.RevokedAt = &now
+			r.db.refreshTokens[id] = t
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"gochi-boilerplate/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevocationStore adalah padanan repository.RevocationStore tanpa cache, karena datanya sudah di memori
+type RevocationStore struct {
+	db *DB
+}
+
+func NewRevocationStore(db *DB) *RevocationStore {
+	return &RevocationStore{db: db}
+}
+
+var _ repository.TokenRevoker = (*RevocationStore)(nil)
+
+func (s *RevocationStore) RevokeToken(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return errConstraint("")
+	}
+	if _, ok := s.db.users[id]; !ok {
+		return errConstraint("revoked_tokens_user_id_fkey")
+	}
+	if _, ok := s.db.revokedTokens[jti]; !ok {
+		s.db.revokedTokens[jti] = revokedToken{userID: userID, expiresAt: expiresAt}
+	}
+	return nil
+}
+
+func (s *RevocationStore) RevokeAllForUser(ctx context.Context, userID string) error {
+	return s.RevokeAllForUserBefore(ctx, userID, time.Now(), "")
+}
+
+func (s *RevocationStore) RevokeAllForUserBefore(ctx context.Context, userID string, cutoff time.Time, exemptJTI string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.cutoffs[userID] = userCutoff{before: cutoff, exemptJTI: exemptJTI}
+	return nil
+}
+
+func (s *RevocationStore) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.revokedTokens[jti]; jti != "" && ok {
+		return true, nil
+	}
+	cutoff, ok := s.db.cutoffs[userID]
+	return ok && issuedAt.Before(cutoff.before) && (jti == "" || jti != cutoff.exemptJTI), nil
+}
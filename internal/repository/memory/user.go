@@ -0,0 +1,297 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserRepository struct {
+	db *DB
+}
+
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+var _ repository.UserStore = (*UserRepository)(nil)
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := model.RolePermissions[user.Role]; !ok {
+		return errConstraint("") // Nilai di luar ENUM user_role ditolak database
+	}
+	if _, ok := r.db.users[user.ID]; ok {
+		return errConflict("users_pkey")
+	}
+	if r.db.emailTaken(user.Email, uuid.Nil) {
+		return errConflict("users_email_key")
+	}
+	r.db.users[user.ID] = *user
+	return nil
+}
+
+// emailTaken memeriksa keunikan email seperti constraint UNIQUE (case-sensitive), kecuali milik pengguna except
+func (db *DB) emailTaken(email string, except uuid.UUID) bool {
+	for _, u := range db.users {
+		if u.Email == email && u.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, u := range r.db.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, errNotFound()
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	u, ok := r.db.users[id]
+	if !ok {
+		return nil, errNotFound()
+	}
+	return &u, nil
+}
+
+// update menjalankan fn terhadap pengguna dengan ID id di bawah lock, ErrNotFound jika tidak ada
+func (r *UserRepository) update(id uuid.UUID, fn func(u *model.User) error) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	u, ok := r.db.users[id]
+	if !ok {
+		return errNotFound()
+	}
+	if err := fn(&u); err != nil {
+		return err
+	}
+	r.db.users[id] = u
+	return nil
+}
+
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, id uuid.UUID, policy model.LockoutPolicy) (*time.Time, error) {
+	var lockedUntil *time.Time
+	err := r.update(id, func(u *model.User) error {
+		u.FailedLoginAttempts++
+		if u.FailedLoginAttempts >= policy.Threshold {
+			exp := min(u.FailedLoginAttempts-policy.Threshold, 30)
+			seconds := min(policy.Base.Seconds()*math.Pow(2, float64(exp)), policy.Max.Seconds())
+			until := time.Now().Add(time.Duration(seconds * float64(time.Second)))
+			u.LockedUntil = &until
+		}
+		lockedUntil = u.LockedUntil
+		return nil
+	})
+	return lockedUntil, err
+}
+
+func (r *UserRepository) ResetLoginFailures(ctx context.Context, id uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// Sama seperti versi Postgres: pengguna yang tidak ada bukan error
+	if u, ok := r.db.users[id]; ok {
+		u.FailedLoginAttempts, u.LockedUntil = 0, nil
+		r.db.users[id] = u
+	}
+	return nil
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	return r.update(id, func(u *model.User) error {
+		if u.Email != email {
+			return errNotFound()
+		}
+		now := time.Now()
+		u.EmailVerifiedAt, u.UpdatedAt = &now, now
+		return nil
+	})
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return r.update(id, func(u *model.User) error {
+		u.Password = passwordHash
+		u.FailedLoginAttempts, u.LockedUntil = 0, nil
+		u.PasswordResetRequired = false
+		u.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+func (r *UserRepository) UpdateProfile(ctx context.Context, user *model.User) error {
+	return r.update(user.ID, func(u *model.User) error {
+		if r.db.emailTaken(user.Email, user.ID) {
+			return errConflict("users_email_key")
+		}
+		u.FullName, u.Email, u.EmailVerifiedAt, u.UpdatedAt = user.FullName, user.Email, user.EmailVerifiedAt, user.UpdatedAt
+		return nil
+	})
+}
+
+// DeleteUser menghapus pengguna beserta token-tokennya (ON DELETE CASCADE) dan melepas
+// kepemilikan produknya (ON DELETE SET NULL). Batas logout-all tetap disimpan.
+func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[id]; !ok {
+		return errNotFound()
+	}
+	delete(r.db.users, id)
+
+	for pid, p := range r.db.products {
+		if p.UserID != nil && *p.UserID == id {
+			p.UserID = nil
+			r.db.products[pid] = p
+		}
+	}
+	for tid, t := range r.db.refreshTokens {
+		if t.UserID == id {
+			delete(r.db.refreshTokens, tid)
+		}
+	}
+	for tid, t := range r.db.userTokens {
+		if t.UserID == id {
+			delete(r.db.userTokens, tid)
+		}
+	}
+	for jti, t := range r.db.revokedTokens {
+		if t.userID == id.String() {
+			delete(r.db.revokedTokens, jti)
+		}
+	}
+	return nil
+}
+
+// userSortColumns adalah padanan userSortColumns pada repository Postgres
+var userSortColumns = map[string]compareFunc[model.User]{
+	"full_name":  func(a, b model.User) int { return cmp.Compare(a.FullName, b.FullName) },
+	"email":      func(a, b model.User) int { return cmp.Compare(a.Email, b.Email) },
+	"created_at": func(a, b model.User) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b model.User) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context, filter model.UserFilter, page model.PageParams) ([]model.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	users := []model.User{}
+	for _, u := range r.db.users {
+		if matchUser(u, filter) {
+			users = append(users, u)
+		}
+	}
+	return paginate(users, page, userSortColumns, func(u model.User) model.Cursor {
+		return model.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+	}), nil
+}
+
+func (r *UserRepository) CountUsers(ctx context.Context, filter model.UserFilter) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var total int64
+	for _, u := range r.db.users {
+		if matchUser(u, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// matchUser adalah padanan buildUserWhere pada repository Postgres
+func matchUser(u model.User, filter model.UserFilter) bool {
+	if filter.Query != "" && !containsFold(u.FullName, filter.Query) && !containsFold(u.Email, filter.Query) {
+		return false
+	}
+	if filter.Role != "" && u.Role != filter.Role {
+		return false
+	}
+	if filter.Disabled != nil && u.IsDisabled() != *filter.Disabled {
+		return false
+	}
+	return true
+}
+
+// containsFold meniru ILIKE '%sub%'
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string, audit *model.AuditEntry) error {
+	if _, ok := model.RolePermissions[role]; !ok {
+		return errConstraint("") // Nilai di luar ENUM user_role ditolak database
+	}
+	return r.updateWithAudit(id, audit, func(u *model.User) {
+		u.Role = role
+	})
+}
+
+func (r *UserRepository) SetDisabled(ctx context.Context, id uuid.UUID, disabled bool, audit *model.AuditEntry) error {
+	return r.updateWithAudit(id, audit, func(u *model.User) {
+		if !disabled {
+			u.DisabledAt, u.FailedLoginAttempts, u.LockedUntil = nil, 0, nil
+			return
+		}
+		if u.DisabledAt == nil {
+			now := time.Now()
+			u.DisabledAt = &now
+		}
+	})
+}
+
+func (r *UserRepository) RequirePasswordReset(ctx context.Context, id uuid.UUID, audit *model.AuditEntry) error {
+	return r.updateWithAudit(id, audit, func(u *model.User) {
+		u.PasswordResetRequired = true
+	})
+}
+
+func (r *UserRepository) RecordAudit(ctx context.Context, audit *model.AuditEntry) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.appendAudit(audit)
+	return nil
+}
+
+// updateWithAudit mengubah pengguna dan menambah audit entry secara atomik
+func (r *UserRepository) updateWithAudit(id uuid.UUID, audit *model.AuditEntry, fn func(u *model.User)) error {
+	return r.update(id, func(u *model.User) error {
+		fn(u)
+		u.UpdatedAt = time.Now()
+		r.db.appendAudit(audit)
+		return nil
+	})
+}
+
+// appendAudit mengisi ID dan waktu yang masih kosong seperti insertAuditEntry; lock harus sudah dipegang
+func (db *DB) appendAudit(audit *model.AuditEntry) {
+	if audit.ID == uuid.Nil {
+		audit.ID = uuid.New()
+	}
+	if audit.CreatedAt.IsZero() {
+		audit.CreatedAt = time.Now()
+	}
+	if audit.Details == nil {
+		audit.Details = map[string]any{}
+	}
+	db.audit = append(db.audit, *audit)
+}
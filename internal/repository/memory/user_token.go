@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/repository"
+	"time"
+)
+
+type UserTokenRepository struct {
+	db *DB
+}
+
+func NewUserTokenRepository(db *DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+var _ repository.UserTokenStore = (*UserTokenRepository)(nil)
+
+func (r *UserTokenRepository) CreateUserToken(ctx context.Context, token *model.UserToken) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[token.UserID]; !ok {
+		return errConstraint("user_tokens_user_id_fkey")
+	}
+	for _, t := range r.db.userTokens {
+		if t.TokenHash == token.TokenHash {
+			return errConflict("user_tokens_token_hash_key")
+		}
+	}
+
+	// Token lama dengan tujuan yang sama dibatalkan, hanya token terbaru yang berlaku
+	for id, t := range r.db.userTokens {
+		if t.UserID == token.UserID && t.Purpose == token.Purpose && t.UsedAt == nil {
+			usedAt := token.CreatedAt
+			t.UsedAt = &usedAt
+			r.db.userTokens[id] = t
+		}
+	}
+	r.db.userTokens[token.ID] = *token
+	return nil
+}
+
+func (r *UserTokenRepository) ConsumeUserToken(ctx context.Context, hash, purpose string) (*model.UserToken, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now()
+	for id, t := range r.db.userTokens {
+		if t.TokenHash == hash && t.Purpose == purpose && t.UsedAt == nil && t.ExpiresAt.After(now) {
+			t.UsedAt = &now
+			r.db.userTokens[id] = t
+			return &t, nil
+		}
+	}
+	return nil, errNotFound()
+}
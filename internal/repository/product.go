@@ -2,63 +2,343 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"gochi-boilerplate/internal/model"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 )
 
 type ProductRepository struct {
-	DB *pgxpool.Pool
+	DB DBTX
 }
 
-func NewProductRepository(db *pgxpool.Pool) *ProductRepository {
+func NewProductRepository(db DBTX) *ProductRepository {
 	return &ProductRepository{DB: db}
 }
 
+// CreateProduct menyimpan produk baru dan mengisi product.Version dengan versi awalnya
 func (r *ProductRepository) CreateProduct(ctx context.Context, product *model.Product) error {
-	query := `INSERT INTO products (id, name, price, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.DB.Exec(ctx, query, product.ID, product.Name, product.Price, product.UserID, product.CreatedAt, product.UpdatedAt)
-	return err
+	query := `INSERT INTO products (id, name, price, user_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING version`
+	err := r.DB.QueryRow(ctx, query, product.ID, product.Name, product.Price, product.UserID, product.CreatedAt, product.UpdatedAt).
+		Scan(&product.Version)
+	return translateError(ctx, err)
 }
 
-func (r *ProductRepository) GetAllProducts(ctx context.Context) ([]model.Product, error) {
+// productColumns adalah kolom produk yang dibaca oleh semua query; tabel products selalu diberi alias p
+const productColumns = `p.id, p.name, p.price, p.user_id, p.created_at, p.updated_at, p.version, p.deleted_at`
+
+// selectProducts menyusun awal query SELECT produk. Jika include.Owner aktif, data pemilik
+// ikut diambil lewat LEFT JOIN dalam query yang sama (tanpa N+1 query).
+func selectProducts(include model.ProductInclude) string {
+	if include.Owner {
+		return `SELECT ` + productColumns + `, u.id, u.full_name FROM products p LEFT JOIN users u ON u.id = p.user_id`
+	}
+	return `SELECT ` + productColumns + ` FROM products p`
+}
+
+// scanProduct membaca satu baris hasil selectProducts
+func scanProduct(row pgx.Row, include model.ProductInclude) (model.Product, error) {
+	var p model.Product
+	dest := []any{&p.ID, &p.Name, &p.Price, &p.UserID, &p.CreatedAt, &p.UpdatedAt, &p.Version, &p.DeletedAt}
+
+	// Kolom pemilik bisa NULL jika produk tidak punya pemilik (user dihapus)
+	var ownerID *uuid.UUID
+	var ownerName *string
+	if include.Owner {
+		dest = append(dest, &ownerID, &ownerName)
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return p, err
+	}
+	if ownerID != nil {
+		p.Owner = &model.UserSummary{ID: *ownerID, FullName: *ownerName}
+	}
+	return p, nil
+}
+
+// GetAllProducts mengambil daftar produk sesuai filter, pengurutan, dan paginasi. Produk di trash
+// tidak ikut, kecuali filter.Trashed aktif (yang hanya mengambil produk di trash).
+// Pada mode keyset, satu baris ekstra diambil (Limit+1) agar pemanggil tahu apakah masih ada halaman berikutnya.
+func (r *ProductRepository) GetAllProducts(ctx context.Context, filter model.ProductFilter, page model.PageParams, include model.ProductInclude) ([]model.Product, error) {
 	products := []model.Product{}
-	query := `SELECT id, name, price, created_at, updated_at FROM products`
-	rows, err := r.DB.Query(ctx, query)
+	where, args := buildProductWhere(filter)
+
+	if page.After != nil {
+		// Keyset pada (created_at, id); arah perbandingan mengikuti arah pengurutan created_at
+		op := ">"
+		if len(page.Sort) > 0 && page.Sort[0].Desc {
+			op = "<"
+		}
+		args = append(args, page.After.CreatedAt, page.After.ID)
+		where = append(where, fmt.Sprintf("(p.created_at, p.id) %s ($%d, $%d)", op, len(args)-1, len(args)))
+	}
+
+	query := selectProducts(include)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + buildOrderBy(page.Sort, productSortColumns, "p")
+
+	limit := page.Limit
+	if !page.OffsetMode() {
+		limit++
+	}
+	args = append(args, limit, page.Offset())
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.DB.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateError(ctx, err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var p model.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.CreatedAt, &p.UpdatedAt); err != nil {
-			return nil, err
+		p, err := scanProduct(rows, include)
+		if err != nil {
+			return nil, translateError(ctx, err)
 		}
 		products = append(products, p)
 	}
-	return products, nil
+	return products, translateError(ctx, rows.Err())
 }
 
-func (r *ProductRepository) GetProductByID(ctx context.Context, id uuid.UUID) (*model.Product, error) {
-	var p model.Product
-	query := `SELECT id, name, price, created_at, updated_at FROM products WHERE id = $1`
-	err := r.DB.QueryRow(ctx, query, id).Scan(&p.ID, &p.Name, &p.Price, &p.CreatedAt, &p.UpdatedAt)
+// CountProducts menghitung jumlah produk yang cocok dengan filter (dipakai pada mode offset)
+func (r *ProductRepository) CountProducts(ctx context.Context, filter model.ProductFilter) (int64, error) {
+	where, args := buildProductWhere(filter)
+	query := `SELECT COUNT(*) FROM products p`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int64
+	err := r.DB.QueryRow(ctx, query, args...).Scan(&total)
+	return total, translateError(ctx, err)
+}
+
+// SearchProducts mencari produk dengan full-text search (prefix matching) dan mengurutkannya
+// berdasarkan relevansi. Mengembalikan satu halaman hasil beserta total seluruh hasil.
+func (r *ProductRepository) SearchProducts(ctx context.Context, text string, page model.PageParams) ([]model.ProductSearchResult, int64, error) {
+	results := []model.ProductSearchResult{}
+	tsquery := buildPrefixTSQuery(text)
+	if tsquery == "" {
+		return results, 0, nil
+	}
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM products p WHERE p.search_vector @@ to_tsquery('simple', $1) AND p.deleted_at IS NULL`
+	if err := r.DB.QueryRow(ctx, countQuery, tsquery).Scan(&total); err != nil {
+		return nil, 0, translateError(ctx, err)
+	}
+
+	query := `SELECT ` + productColumns + `,
+			ts_rank(p.search_vector, query) AS rank,
+			ts_headline('simple', ` + escapedProductName + `, query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS highlight
+		FROM products p, to_tsquery('simple', $1) AS query
+		WHERE p.search_vector @@ query AND p.deleted_at IS NULL
+		ORDER BY rank DESC, p.created_at DESC, p.id DESC
+		LIMIT $2 OFFSET $3`
+	rows, err := r.DB.Query(ctx, query, tsquery, page.Limit, page.Offset())
 	if err != nil {
-		return nil, err
+		return nil, 0, translateError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p model.ProductSearchResult
+		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.UserID, &p.CreatedAt, &p.UpdatedAt, &p.Version, &p.DeletedAt, &p.Rank, &p.Highlight); err != nil {
+			return nil, 0, translateError(ctx, err)
+		}
+		results = append(results, p)
+	}
+	return results, total, translateError(ctx, rows.Err())
+}
+
+// escapedProductName adalah nama produk yang di-escape HTML dengan hasil yang sama seperti
+// html.EscapeString. Nama di-escape sebelum diberi <mark> agar highlight aman ditampilkan sebagai HTML.
+const escapedProductName = `replace(replace(replace(replace(replace(p.name,
+	'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+
+// buildPrefixTSQuery mengubah input pengguna menjadi tsquery dengan prefix matching,
+// misal "lap gam" menjadi "lap:* & gam:*". Karakter selain huruf dan angka dibuang
+// agar input tidak bisa menyisipkan operator tsquery.
+func buildPrefixTSQuery(text string) string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		terms = append(terms, strings.ToLower(word)+":*")
+	}
+	return strings.Join(terms, " & ")
+}
+
+// buildProductWhere menyusun kondisi WHERE dan argumennya dari filter produk
+func buildProductWhere(filter model.ProductFilter) ([]string, []any) {
+	where := []string{"p.deleted_at IS NULL"}
+	if filter.Trashed {
+		where = []string{"p.deleted_at IS NOT NULL"}
+	}
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.MinPrice != nil {
+		add("p.price >= $%d", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		add("p.price <= $%d", *filter.MaxPrice)
+	}
+	if filter.OwnerID != nil {
+		add("p.user_id = $%d", *filter.OwnerID)
+	}
+	if filter.Query != "" {
+		add("p.name ILIKE $%d", "%"+escapeLike(filter.Query)+"%")
+	}
+	if filter.CreatedAfter != nil {
+		add("p.created_at > $%d", *filter.CreatedAfter)
+	}
+	return where, args
+}
+
+// productSortColumns memetakan nama field sort ke kolom database; hanya kolom di sini yang boleh masuk ke ORDER BY
+var productSortColumns = map[string]string{
+	"name":       "p.name",
+	"price":      "p.price",
+	"created_at": "p.created_at",
+	"updated_at": "p.updated_at",
+	"deleted_at": "p.deleted_at",
+}
+
+// buildOrderBy menyusun klausa ORDER BY dari kolom yang diizinkan di columns, dengan id (milik tabel
+// beralias alias) sebagai penentu urutan terakhir agar hasil stabil
+func buildOrderBy(sort []model.SortField, columns map[string]string, alias string) string {
+	var parts []string
+	idDir := "DESC"
+	for _, s := range sort {
+		column, ok := columns[s.Field]
+		if !ok {
+			continue
+		}
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		parts = append(parts, column+" "+dir)
+		idDir = dir
+	}
+	if len(parts) == 0 {
+		parts = append(parts, alias+".created_at DESC")
+	}
+	return strings.Join(append(parts, alias+".id "+idDir), ", ")
+}
+
+// escapeLike meng-escape karakter wildcard LIKE agar input pengguna dicocokkan apa adanya
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// GetProductByID mengambil produk yang belum dihapus; produk di trash dianggap tidak ada
+func (r *ProductRepository) GetProductByID(ctx context.Context, id uuid.UUID, include model.ProductInclude) (*model.Product, error) {
+	query := selectProducts(include) + ` WHERE p.id = $1 AND p.deleted_at IS NULL`
+	p, err := scanProduct(r.DB.QueryRow(ctx, query, id), include)
+	if err != nil {
+		return nil, translateError(ctx, err)
 	}
 	return &p, nil
 }
 
+// GetTrashedProductByID mengambil produk yang ada di trash
+func (r *ProductRepository) GetTrashedProductByID(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	query := selectProducts(model.ProductInclude{}) + ` WHERE p.id = $1 AND p.deleted_at IS NOT NULL`
+	p, err := scanProduct(r.DB.QueryRow(ctx, query, id), model.ProductInclude{})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &p, nil
+}
+
+// UpdateProduct menyimpan nama dan harga produk hanya jika version di database masih sama dengan
+// product.Version, lalu mengisi product.Version dengan versi barunya. Pengecekan dan penulisan terjadi
+// dalam satu statement, sehingga dua perubahan bersamaan tidak bisa saling menimpa: yang kalah
+// mendapat ErrStale.
 func (r *ProductRepository) UpdateProduct(ctx context.Context, product *model.Product) error {
-	query := `UPDATE products SET name = $1, price = $2, updated_at = $3 WHERE id = $4`
-	_, err := r.DB.Exec(ctx, query, product.Name, product.Price, product.UpdatedAt, product.ID)
-	return err
+	query := `UPDATE products SET name = $1, price = $2, updated_at = $3, version = version + 1
+		WHERE id = $4 AND deleted_at IS NULL AND version = $5
+		RETURNING version`
+	err := r.DB.QueryRow(ctx, query, product.Name, product.Price, product.UpdatedAt, product.ID, product.Version).
+		Scan(&product.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r.staleOrMissing(ctx, product.ID)
+	}
+	return translateError(ctx, err)
 }
 
-func (r *ProductRepository) DeleteProduct(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM products WHERE id = $1`
-	_, err := r.DB.Exec(ctx, query, id)
-	return err
-}
\ No newline at end of file
+// DeleteProduct memindahkan produk ke trash (soft delete) jika version-nya masih sama. Produk yang
+// sudah di trash dianggap tidak ada.
+func (r *ProductRepository) DeleteProduct(ctx context.Context, id uuid.UUID, version int) error {
+	query := `UPDATE products SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND version = $2`
+	tag, err := r.DB.Exec(ctx, query, id, version)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return r.staleOrMissing(ctx, id)
+	}
+	return nil
+}
+
+// staleOrMissing menentukan penyebab update bersyarat version yang tidak mengenai baris apa pun:
+// ErrStale jika produknya masih ada (berarti version-nya sudah berubah), ErrNotFound jika tidak
+func (r *ProductRepository) staleOrMissing(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	err := r.DB.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if exists {
+		return &DBError{Kind: ErrStale, Err: fmt.Errorf("product %s was modified concurrently", id)}
+	}
+	return &DBError{Kind: ErrNotFound, Err: pgx.ErrNoRows}
+}
+
+// RestoreProduct mengeluarkan produk dari trash dan mengembalikan datanya yang terbaru
+func (r *ProductRepository) RestoreProduct(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	query := `UPDATE products AS p SET deleted_at = NULL, updated_at = NOW(), version = p.version + 1
+		WHERE p.id = $1 AND p.deleted_at IS NOT NULL
+		RETURNING ` + productColumns
+	p, err := scanProduct(r.DB.QueryRow(ctx, query, id), model.ProductInclude{})
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &p, nil
+}
+
+// PurgeProduct menghapus produk secara permanen, baik yang masih aktif maupun yang ada di trash
+func (r *ProductRepository) PurgeProduct(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM products WHERE id = $1`, id)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &DBError{Kind: ErrNotFound, Err: pgx.ErrNoRows}
+	}
+	return nil
+}
+
+// PurgeTrashedProducts menghapus permanen produk yang masuk trash sebelum deletedBefore
+// dan mengembalikan jumlah produk yang dihapus
+func (r *ProductRepository) PurgeTrashedProducts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM products WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, translateError(ctx, err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gochi-boilerplate/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRefreshTokenReused dikembalikan ketika refresh token yang akan dirotasi ternyata sudah dicabut,
+// misalnya karena dua request memakai token yang sama secara bersamaan.
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+
+type RefreshTokenRepository struct {
+	DB DBTX
+}
+
+func NewRefreshTokenRepository(db DBTX) *RefreshTokenRepository {
+	return &RefreshTokenRepository{DB: db}
+}
+
+func (r *RefreshTokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at) 
+			VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.DB.Exec(ctx, query, token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	return translateError(ctx, err)
+}
+
+func (r *RefreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var t model.RefreshToken
+	query := `SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at 
+			FROM refresh_tokens WHERE token_hash = $1`
+	err := r.DB.QueryRow(ctx, query, hash).Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.RevokedAt, &t.ReplacedBy, &t.CreatedAt)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &t, nil
+}
+
+// RotateRefreshToken mencabut token lama dan menyimpan penggantinya dalam satu transaksi.
+// Jika token lama sudah dicabut lebih dulu, ErrRefreshTokenReused dikembalikan dan tidak ada yang disimpan.
+func (r *RefreshTokenRepository) RotateRefreshToken(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3 AND revoked_at IS NULL`,
+		time.Now(), next.ID, oldID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRefreshTokenReused
+	}
+
+	query := `INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at) 
+			VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := tx.Exec(ctx, query, next.ID, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.CreatedAt); err != nil {
+		return translateError(ctx, err)
+	}
+
+	return translateError(ctx, tx.Commit(ctx))
+}
+
+// RevokeFamily mencabut semua refresh token yang masih aktif dalam satu family
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+	_, err := r.DB.Exec(ctx, query, time.Now(), familyID)
+	return translateError(ctx, err)
+}
+
+// RevokeAllForUser mencabut semua refresh token yang masih aktif milik seorang pengguna
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+	_, err := r.DB.Exec(ctx, query, time.Now(), userID)
+	return translateError(ctx, err)
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RevocationStore menyimpan daftar access token (JWT) yang dicabut sebelum kedaluwarsa.
+// Data utama ada di Postgres agar berlaku di semua replika, sedangkan hasil pengecekan
+// disimpan sementara di memori supaya middleware tidak selalu menembak database.
+type RevocationStore struct {
+	DB       DBTX
+	CacheTTL time.Duration // Berapa lama hasil "tidak dicabut" dipercaya sebelum dicek ulang ke database
+
+	mu        sync.RWMutex
+	tokens    map[string]cachedRevocation // jti -> status
+	users     map[string]cachedCutoff     // user_id -> batas waktu logout-all
+	lastSweep time.Time
+}
+
+type cachedRevocation struct {
+	revoked bool
+	until   time.Time
+}
+
+type cachedCutoff struct {
+	cutoff    *time.Time
+	exemptJTI string
+	until     time.Time
+}
+
+func NewRevocationStore(db DBTX, cacheTTL time.Duration) *RevocationStore {
+	return &RevocationStore{
+		DB:       db,
+		CacheTTL: cacheTTL,
+		tokens:   make(map[string]cachedRevocation),
+		users:    make(map[string]cachedCutoff),
+	}
+}
+
+// RevokeToken mencabut satu access token berdasarkan jti sampai token tersebut kedaluwarsa
+func (s *RevocationStore) RevokeToken(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`
+	if _, err := s.DB.Exec(ctx, query, jti, userID, expiresAt); err != nil {
+		return translateError(ctx, err)
+	}
+	// Baris yang token-nya sudah kedaluwarsa tidak perlu disimpan lagi
+	if _, err := s.DB.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < NOW()`); err != nil {
+		return translateError(ctx, err)
+	}
+
+	s.mu.Lock()
+	s.tokens[jti] = cachedRevocation{revoked: true, until: expiresAt}
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeAllForUser mencabut semua access token milik pengguna yang diterbitkan sebelum saat ini
+func (s *RevocationStore) RevokeAllForUser(ctx context.Context, userID string) error {
+	return s.RevokeAllForUserBefore(ctx, userID, time.Now(), "")
+}
+
+// RevokeAllForUserBefore mencabut semua access token milik pengguna yang diterbitkan sebelum cutoff,
+// kecuali token dengan jti exemptJTI (kosong jika tidak ada). Pengecualian dari pencabutan
+// sebelumnya ikut terhapus.
+func (s *RevocationStore) RevokeAllForUserBefore(ctx context.Context, userID string, cutoff time.Time, exemptJTI string) error {
+	query := `INSERT INTO user_token_revocations (user_id, revoked_before, exempt_jti) VALUES ($1, $2, NULLIF($3, ''))
+			ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before, exempt_jti = EXCLUDED.exempt_jti`
+	if _, err := s.DB.Exec(ctx, query, userID, cutoff, exemptJTI); err != nil {
+		return translateError(ctx, err)
+	}
+
+	s.mu.Lock()
+	s.users[userID] = cachedCutoff{cutoff: &cutoff, exemptJTI: exemptJTI, until: time.Now().Add(s.CacheTTL)}
+	s.mu.Unlock()
+	return nil
+}
+
+// IsRevoked memeriksa apakah token dengan jti dan waktu terbit tertentu sudah dicabut,
+// baik secara individual (logout) maupun massal (logout-all)
+func (s *RevocationStore) IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error) {
+	if jti != "" {
+		revoked, err := s.isTokenRevoked(ctx, jti)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	entry, err := s.userCutoff(ctx, userID)
+	if err != nil {
+		return false, translateError(ctx, err)
+	}
+	// Klaim iat hanya berpresisi detik, jadi token yang terbit di detik yang sama dengan logout-all ikut dicabut
+	return entry.cutoff != nil && issuedAt.Before(*entry.cutoff) && (jti == "" || jti != entry.exemptJTI), nil
+}
+
+func (s *RevocationStore) isTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	now := time.Now()
+	s.mu.RLock()
+	entry, ok := s.tokens[jti]
+	s.mu.RUnlock()
+	if ok && now.Before(entry.until) {
+		return entry.revoked, nil
+	}
+
+	var expiresAt time.Time
+	err := s.DB.QueryRow(ctx, `SELECT expires_at FROM revoked_tokens WHERE jti = $1`, jti).Scan(&expiresAt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, translateError(ctx, err)
+	}
+
+	entry = cachedRevocation{revoked: err == nil, until: now.Add(s.CacheTTL)}
+	if entry.revoked {
+		entry.until = expiresAt
+	}
+	s.store(func() { s.tokens[jti] = entry })
+	return entry.revoked, nil
+}
+
+func (s *RevocationStore) userCutoff(ctx context.Context, userID string) (cachedCutoff, error) {
+	now := time.Now()
+	s.mu.RLock()
+	entry, ok := s.users[userID]
+	s.mu.RUnlock()
+	if ok && now.Before(entry.until) {
+		return entry, nil
+	}
+
+	var cutoff time.Time
+	var exemptJTI *string
+	err := s.DB.QueryRow(ctx, `SELECT revoked_before, exempt_jti FROM user_token_revocations WHERE user_id = $1`, userID).
+		Scan(&cutoff, &exemptJTI)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return cachedCutoff{}, translateError(ctx, err)
+	}
+
+	entry = cachedCutoff{until: now.Add(s.CacheTTL)}
+	if err == nil {
+		entry.cutoff = &cutoff
+		if exemptJTI != nil {
+			entry.exemptJTI = *exemptJTI
+		}
+	}
+	s.store(func() { s.users[userID] = entry })
+	return entry, nil
+}
+
+// store menjalankan perubahan cache di bawah lock dan sesekali membuang entri yang sudah basi
+func (s *RevocationStore) store(update func()) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update()
+	if now.Sub(s.lastSweep) < s.CacheTTL {
+		return
+	}
+	for k, v := range s.tokens {
+		if now.After(v.until) {
+			delete(s.tokens, k)
+		}
+	}
+	for k, v := range s.users {
+		if now.After(v.until) {
+			delete(s.users, k)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"gochi-boilerplate/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Interface penyimpanan yang dipakai handler. Implementasi Postgres ada di package ini,
+// sedangkan implementasi in-memory untuk test ada di package repository/memory.
+// Semua implementasi wajib mengembalikan error domain yang sama (ErrNotFound, ErrConflict, ...).
+
+// ProductStore menyimpan dan membaca data produk. DeleteProduct memindahkan produk ke trash;
+// penghapusan permanen lewat PurgeProduct dan PurgeTrashedProducts. UpdateProduct dan DeleteProduct
+// hanya berhasil jika version produk belum berubah sejak dibaca, selain itu mengembalikan ErrStale.
+type ProductStore interface {
+	CreateProduct(ctx context.Context, product *model.Product) error
+	GetAllProducts(ctx context.Context, filter model.ProductFilter, page model.PageParams, include model.ProductInclude) ([]model.Product, error)
+	CountProducts(ctx context.Context, filter model.ProductFilter) (int64, error)
+	SearchProducts(ctx context.Context, text string, page model.PageParams) ([]model.ProductSearchResult, int64, error)
+	GetProductByID(ctx context.Context, id uuid.UUID, include model.ProductInclude) (*model.Product, error)
+	UpdateProduct(ctx context.Context, product *model.Product) error
+	DeleteProduct(ctx context.Context, id uuid.UUID, version int) error
+
+	GetTrashedProductByID(ctx context.Context, id uuid.UUID) (*model.Product, error)
+	RestoreProduct(ctx context.Context, id uuid.UUID) (*model.Product, error)
+	PurgeProduct(ctx context.Context, id uuid.UUID) error
+	PurgeTrashedProducts(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// UserStore menyimpan dan membaca data pengguna, termasuk operasi admin beserta audit log-nya
+type UserStore interface {
+	CreateUser(ctx context.Context, user *model.User) error
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	RecordLoginFailure(ctx context.Context, id uuid.UUID, policy model.LockoutPolicy) (*time.Time, error)
+	ResetLoginFailures(ctx context.Context, id uuid.UUID) error
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	UpdateProfile(ctx context.Context, user *model.User) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+
+	ListUsers(ctx context.Context, filter model.UserFilter, page model.PageParams) ([]model.User, error)
+	CountUsers(ctx context.Context, filter model.UserFilter) (int64, error)
+	UpdateRole(ctx context.Context, id uuid.UUID, role string, audit *model.AuditEntry) error
+	SetDisabled(ctx context.Context, id uuid.UUID, disabled bool, audit *model.AuditEntry) error
+	RequirePasswordReset(ctx context.Context, id uuid.UUID, audit *model.AuditEntry) error
+	RecordAudit(ctx context.Context, audit *model.AuditEntry) error
+}
+
+// RefreshTokenStore menyimpan refresh token beserta rantai rotasinya
+type RefreshTokenStore interface {
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// TokenRevoker mencabut access token sebelum kedaluwarsa dan memeriksa statusnya
+type TokenRevoker interface {
+	RevokeToken(ctx context.Context, jti, userID string, expiresAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userID string) error
+	RevokeAllForUserBefore(ctx context.Context, userID string, cutoff time.Time, exemptJTI string) error
+	IsRevoked(ctx context.Context, jti, userID string, issuedAt time.Time) (bool, error)
+}
+
+// UserTokenStore menyimpan token sekali pakai untuk verifikasi email dan reset password
+type UserTokenStore interface {
+	CreateUserToken(ctx context.Context, token *model.UserToken) error
+	ConsumeUserToken(ctx context.Context, hash, purpose string) (*model.UserToken, error)
+}
+
+var (
+	_ ProductStore      = (*ProductRepository)(nil)
+	_ UserStore         = (*UserRepository)(nil)
+	_ RefreshTokenStore = (*RefreshTokenRepository)(nil)
+	_ TokenRevoker      = (*RevocationStore)(nil)
+	_ UserTokenStore    = (*UserTokenRepository)(nil)
+)
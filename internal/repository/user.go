@@ -3,15 +3,17 @@ package repository
 import (
 	"context"
 	"gochi-boilerplate/internal/model"
+	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type UserRepository struct {
-	DB *pgxpool.Pool
+	DB DBTX
 }
 
-func NewUserRepository(db *pgxpool.Pool) *UserRepository {
+func NewUserRepository(db DBTX) *UserRepository {
 	return &UserRepository{DB: db}
 }
 
@@ -19,16 +21,124 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error
 	query := `INSERT INTO users (id, full_name, email, password, role, created_at, updated_at) 
 			VALUES ($1, $2, $3, $4, $5, $6, $7)`
 	_, err := r.DB.Exec(ctx, query, user.ID, user.FullName, user.Email, user.Password, user.Role, user.CreatedAt, user.UpdatedAt)
-	return err
+	return translateError(ctx, err)
 }
 
-func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+// userColumns adalah kolom pengguna yang dibaca oleh semua query, dalam urutan yang dipakai scanUser
+const userColumns = `id, full_name, email, password, role, email_verified_at, failed_login_attempts, locked_until,
+	disabled_at, password_reset_required, created_at, updated_at`
+
+// scanUser membaca satu baris berisi userColumns
+func scanUser(row pgx.Row) (*model.User, error) {
 	var u model.User
-	query := `SELECT id, full_name, email, password, role, created_at, updated_at 
-			FROM users WHERE email = $1`
-	err := r.DB.QueryRow(ctx, query, email).Scan(&u.ID, &u.FullName, &u.Email, &u.Password, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+	err := row.Scan(&u.ID, &u.FullName, &u.Email, &u.Password, &u.Role, &u.EmailVerifiedAt, &u.FailedLoginAttempts, &u.LockedUntil,
+		&u.DisabledAt, &u.PasswordResetRequired, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
-}
\ No newline at end of file
+}
+
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+	u, err := scanUser(r.DB.QueryRow(ctx, query, email))
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return u, nil
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	u, err := scanUser(r.DB.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return u, nil
+}
+
+// RecordLoginFailure menambah hitungan login gagal secara atomik. Setelah hitungan mencapai
+// policy.Threshold, akun dikunci selama policy.Base * 2^(gagal - threshold), maksimal policy.Max.
+// Mengembalikan waktu akhir penguncian (nil jika akun belum dikunci).
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, id uuid.UUID, policy model.LockoutPolicy) (*time.Time, error) {
+	query := `UPDATE users SET
+			failed_login_attempts = failed_login_attempts + 1,
+			locked_until = CASE
+				WHEN failed_login_attempts + 1 >= $2
+				THEN NOW() + LEAST($3 * POWER(2, LEAST(failed_login_attempts + 1 - $2, 30)), $4) * INTERVAL '1 second'
+				ELSE locked_until
+			END
+		WHERE id = $1
+		RETURNING locked_until`
+	var lockedUntil *time.Time
+	err := r.DB.QueryRow(ctx, query, id, policy.Threshold, policy.Base.Seconds(), policy.Max.Seconds()).Scan(&lockedUntil)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return lockedUntil, nil
+}
+
+// ResetLoginFailures menghapus hitungan login gagal dan penguncian setelah login berhasil
+func (r *UserRepository) ResetLoginFailures(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE users SET failed_login_attempts = 0, locked_until = NULL
+		WHERE id = $1 AND (failed_login_attempts > 0 OR locked_until IS NOT NULL)`
+	_, err := r.DB.Exec(ctx, query, id)
+	return translateError(ctx, err)
+}
+
+// MarkEmailVerified menandai email pengguna sudah terverifikasi. Hanya berlaku jika email pengguna
+// masih sama dengan email tujuan token; jika sudah berubah, ErrNotFound dikembalikan.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	query := `UPDATE users SET email_verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND email = $2`
+	tag, err := r.DB.Exec(ctx, query, id, email)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &DBError{Kind: ErrNotFound, Err: pgx.ErrNoRows}
+	}
+	return nil
+}
+
+// UpdatePassword mengganti hash password, menghapus penguncian login, dan mencabut kewajiban reset password
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password = $1, failed_login_attempts = 0, locked_until = NULL,
+			password_reset_required = FALSE, updated_at = NOW()
+		WHERE id = $2`
+	tag, err := r.DB.Exec(ctx, query, passwordHash, id)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &DBError{Kind: ErrNotFound, Err: pgx.ErrNoRows}
+	}
+	return nil
+}
+
+// UpdateProfile menyimpan nama, email, dan status verifikasi email pengguna
+func (r *UserRepository) UpdateProfile(ctx context.Context, user *model.User) error {
+	query := `UPDATE users SET full_name = $1, email = $2, email_verified_at = $3, updated_at = $4
+		WHERE id = $5`
+	tag, err := r.DB.Exec(ctx, query, user.FullName, user.Email, user.EmailVerifiedAt, user.UpdatedAt, user.ID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &DBError{Kind: ErrNotFound, Err: pgx.ErrNoRows}
+	}
+	return nil
+}
+
+// DeleteUser menghapus akun beserta token-tokennya (ON DELETE CASCADE). Produk milik pengguna
+// tetap ada tanpa pemilik (ON DELETE SET NULL).
+func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &DBError{Kind: ErrNotFound, Err: pgx.ErrNoRows}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"gochi-boilerplate/internal/model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// userSortColumns memetakan nama field sort ke kolom database; hanya kolom di sini yang boleh masuk ke ORDER BY
+var userSortColumns = map[string]string{
+	"full_name":  "u.full_name",
+	"email":      "u.email",
+	"created_at": "u.created_at",
+	"updated_at": "u.updated_at",
+}
+
+// ListUsers mengambil daftar pengguna untuk panel admin sesuai filter, pengurutan, dan paginasi.
+// Seperti GetAllProducts, mode keyset mengambil satu baris ekstra (Limit+1).
+func (r *UserRepository) ListUsers(ctx context.Context, filter model.UserFilter, page model.PageParams) ([]model.User, error) {
+	users := []model.User{}
+	where, args := buildUserWhere(filter)
+
+	if page.After != nil {
+		op := ">"
+		if len(page.Sort) > 0 && page.Sort[0].Desc {
+			op = "<"
+		}
+		args = append(args, page.After.CreatedAt, page.After.ID)
+		where = append(where, fmt.Sprintf("(u.created_at, u.id) %s ($%d, $%d)", op, len(args)-1, len(args)))
+	}
+
+	query := `SELECT ` + userColumns + ` FROM users u`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + buildOrderBy(page.Sort, userSortColumns, "u")
+
+	limit := page.Limit
+	if !page.OffsetMode() {
+		limit++
+	}
+	args = append(args, limit, page.Offset())
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, translateError(ctx, err)
+		}
+		users = append(users, *u)
+	}
+	return users, translateError(ctx, rows.Err())
+}
+
+// CountUsers menghitung jumlah pengguna yang cocok dengan filter (dipakai pada mode offset)
+func (r *UserRepository) CountUsers(ctx context.Context, filter model.UserFilter) (int64, error) {
+	where, args := buildUserWhere(filter)
+	query := `SELECT COUNT(*) FROM users u`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int64
+	err := r.DB.QueryRow(ctx, query, args...).Scan(&total)
+	return total, translateError(ctx, err)
+}
+
+// buildUserWhere menyusun kondisi WHERE dan argumennya dari filter pengguna
+func buildUserWhere(filter model.UserFilter) ([]string, []any) {
+	var where []string
+	var args []any
+
+	if filter.Query != "" {
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		where = append(where, fmt.Sprintf("(u.full_name ILIKE $%d OR u.email ILIKE $%d)", len(args), len(args)))
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		where = append(where, fmt.Sprintf("u.role = $%d", len(args)))
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			where = append(where, "u.disabled_at IS NOT NULL")
+		} else {
+			where = append(where, "u.disabled_at IS NULL")
+		}
+	}
+	return where, args
+}
+
+// UpdateRole mengganti peran pengguna dan mencatatnya di audit log dalam satu transaksi
+func (r *UserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string, audit *model.AuditEntry) error {
+	query := `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`
+	return r.updateWithAudit(ctx, audit, query, role, id)
+}
+
+// SetDisabled menonaktifkan atau mengaktifkan kembali akun dan mencatatnya di audit log.
+// Mengaktifkan kembali akun juga menghapus penguncian login.
+func (r *UserRepository) SetDisabled(ctx context.Context, id uuid.UUID, disabled bool, audit *model.AuditEntry) error {
+	query := `UPDATE users SET
+			disabled_at = CASE WHEN $1 THEN COALESCE(disabled_at, NOW()) ELSE NULL END,
+			failed_login_attempts = CASE WHEN $1 THEN failed_login_attempts ELSE 0 END,
+			locked_until = CASE WHEN $1 THEN locked_until ELSE NULL END,
+			updated_at = NOW()
+		WHERE id = $2`
+	return r.updateWithAudit(ctx, audit, query, disabled, id)
+}
+
+// RequirePasswordReset mewajibkan pengguna mengganti password sebelum bisa login lagi
+// dan mencatatnya di audit log. Kewajiban ini dihapus oleh UpdatePassword.
+func (r *UserRepository) RequirePasswordReset(ctx context.Context, id uuid.UUID, audit *model.AuditEntry) error {
+	query := `UPDATE users SET password_reset_required = TRUE, updated_at = NOW() WHERE id = $1`
+	return r.updateWithAudit(ctx, audit, query, id)
+}
+
+// RecordAudit menyimpan catatan tindakan admin yang tidak mengubah data pengguna, misal impersonasi
+func (r *UserRepository) RecordAudit(ctx context.Context, audit *model.AuditEntry) error {
+	return translateError(ctx, insertAuditEntry(ctx, r.DB, audit))
+}
+
+// updateWithAudit menjalankan UPDATE terhadap satu pengguna dan menyimpan audit entry dalam transaksi
+// yang sama, sehingga tidak ada perubahan oleh admin yang tidak tercatat. ErrNotFound dikembalikan
+// jika tidak ada baris yang berubah.
+func (r *UserRepository) updateWithAudit(ctx context.Context, audit *model.AuditEntry, query string, args ...any) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &DBError{Kind: ErrNotFound, Err: pgx.ErrNoRows}
+	}
+	if err := insertAuditEntry(ctx, tx, audit); err != nil {
+		return translateError(ctx, err)
+	}
+	return translateError(ctx, tx.Commit(ctx))
+}
+
+// insertAuditEntry mengisi ID dan waktu yang masih kosong lalu menyimpan audit entry
+func insertAuditEntry(ctx context.Context, db DBTX, audit *model.AuditEntry) error {
+	if audit.ID == uuid.Nil {
+		audit.ID = uuid.New()
+	}
+	if audit.CreatedAt.IsZero() {
+		audit.CreatedAt = time.Now()
+	}
+	if audit.Details == nil {
+		audit.Details = map[string]any{}
+	}
+	query := `INSERT INTO admin_audit_logs (id, actor_id, action, target_user_id, details, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db.Exec(ctx, query, audit.ID, audit.ActorID, audit.Action, audit.TargetUserID, audit.Details, audit.CreatedAt)
+	return err
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"gochi-boilerplate/internal/model"
+)
+
+type UserTokenRepository struct {
+	DB DBTX
+}
+
+func NewUserTokenRepository(db DBTX) *UserTokenRepository {
+	return &UserTokenRepository{DB: db}
+}
+
+// CreateUserToken menyimpan token baru dan membatalkan token lain dengan tujuan yang sama milik
+// pengguna tersebut, sehingga hanya tautan di email terakhir yang berlaku
+func (r *UserTokenRepository) CreateUserToken(ctx context.Context, token *model.UserToken) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE user_tokens SET used_at = $1 WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL`,
+		token.CreatedAt, token.UserID, token.Purpose)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	query := `INSERT INTO user_tokens (id, user_id, purpose, token_hash, email, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err = tx.Exec(ctx, query, token.ID, token.UserID, token.Purpose, token.TokenHash, token.Email, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+
+	return translateError(ctx, tx.Commit(ctx))
+}
+
+// ConsumeUserToken menandai token sebagai terpakai secara atomik dan mengembalikannya.
+// ErrNotFound dikembalikan jika token tidak ada, sudah dipakai, atau sudah kedaluwarsa,
+// sehingga dua request bersamaan dengan token yang sama tidak bisa sama-sama berhasil.
+func (r *UserTokenRepository) ConsumeUserToken(ctx context.Context, hash, purpose string) (*model.UserToken, error) {
+	var t model.UserToken
+	query := `UPDATE user_tokens SET used_at = NOW()
+			WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING id, user_id, purpose, token_hash, email, expires_at, used_at, created_at`
+	err := r.DB.QueryRow(ctx, query, hash, purpose).Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.Email, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt)
+	if err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return &t, nil
+}
@@ -0,0 +1,62 @@
+// Package tracing menyiapkan OpenTelemetry tracing: tracer provider dengan exporter OTLP atau
+// stdout, middleware HTTP yang melanjutkan W3C trace context dari gateway, dan tracer pgx yang
+// mencatat setiap query sebagai child span. Exporter bisa diganti (misal tracetest.InMemoryExporter)
+// sehingga span bisa diperiksa di test tanpa collector.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// instrumentationName adalah nama tracer untuk span yang dibuat package ini
+const instrumentationName = "gochi-boilerplate/internal/tracing"
+
+// Jenis exporter yang didukung
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// NewExporter membuat exporter sesuai jenisnya. Untuk "otlp", endpoint dan header diatur lewat
+// variabel standar OTEL_EXPORTER_OTLP_* (default http://localhost:4318). Mengembalikan nil untuk "none".
+func NewExporter(ctx context.Context, kind string) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", kind)
+	}
+}
+
+// NewProvider membuat tracer provider yang mengirim span ke exporter secara batch.
+// Sampling mengikuti keputusan parent (dari header traceparent gateway); trace baru
+// diambil sampelnya sebesar sampleRatio (0..1).
+func NewProvider(exporter sdktrace.SpanExporter, serviceName string, sampleRatio float64) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(semconv.ServiceName(serviceName))
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+}
+
+// SetGlobal memasang tracer provider dan propagator W3C (traceparent + baggage) secara global
+func SetGlobal(tp *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
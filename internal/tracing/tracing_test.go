@@ -0,0 +1,115 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"gochi-boilerplate/internal/tracing"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+// newRouter memasang middleware tracing pada router chi dengan exporter in-memory. WithSyncer
+// mengekspor span begitu selesai, jadi span bisa diperiksa langsung setelah request.
+func newRouter(t *testing.T, handler http.HandlerFunc) (http.Handler, *tracetest.InMemoryExporter, trace.TracerProvider) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware(tp, propagation.TraceContext{}))
+	r.Get("/products/{id}", handler)
+	return r, exporter, tp
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span named %q in %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func TestMiddlewareContinuesTraceparentAndTracesQueries(t *testing.T) {
+	var queryTracer *tracing.QueryTracer
+	router, exporter, tp := newRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		// Sama seperti yang dilakukan pgx di setiap query: Start dengan context request, End dengan hasilnya
+		ctx := queryTracer.TraceQueryStart(r.Context(), nil, pgx.TraceQueryStartData{SQL: "select id from products where id = $1"})
+		queryTracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+		w.WriteHeader(http.StatusOK)
+	})
+	queryTracer = tracing.NewQueryTracer(tp)
+
+	req := httptest.NewRequest(http.MethodGet, "/products/42", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+
+	server := findSpan(t, spans, "GET /products/{id}")
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %v", server.SpanKind)
+	}
+	if got := server.SpanContext.TraceID().String(); got != parentTraceID {
+		t.Errorf("trace id = %s, want %s from traceparent", got, parentTraceID)
+	}
+	if !server.Parent.IsRemote() || server.Parent.SpanID().String() != parentSpanID {
+		t.Errorf("server span parent = %v, want remote span %s", server.Parent, parentSpanID)
+	}
+
+	query := findSpan(t, spans, "SELECT")
+	if query.SpanKind != trace.SpanKindClient {
+		t.Errorf("query span kind = %v", query.SpanKind)
+	}
+	if query.Parent.SpanID() != server.SpanContext.SpanID() || query.SpanContext.TraceID() != server.SpanContext.TraceID() {
+		t.Errorf("query span parent = %v, want request span %v", query.Parent, server.SpanContext)
+	}
+	if query.Status.Code == codes.Error {
+		t.Errorf("query span status = %v", query.Status)
+	}
+}
+
+func TestMiddlewareStartsNewTraceAndMarksServerErrors(t *testing.T) {
+	var queryTracer *tracing.QueryTracer
+	router, exporter, tp := newRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		ctx := queryTracer.TraceQueryStart(r.Context(), nil, pgx.TraceQueryStartData{SQL: "UPDATE products SET name = $1"})
+		queryTracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	queryTracer = tracing.NewQueryTracer(tp)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/42", nil))
+
+	spans := exporter.GetSpans()
+	server := findSpan(t, spans, "GET /products/{id}")
+	if server.Parent.IsValid() {
+		t.Errorf("server span has parent %v without traceparent", server.Parent)
+	}
+	if server.Status.Code != codes.Error {
+		t.Errorf("server span status = %v, want error for 500", server.Status)
+	}
+	if query := findSpan(t, spans, "UPDATE"); query.Status.Code != codes.Error || len(query.Events) == 0 {
+		t.Errorf("failed query span status = %v, events = %d", query.Status, len(query.Events))
+	}
+}
@@ -1,18 +1,441 @@
 package utils
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/logging"
+	"gochi-boilerplate/internal/model"
+	"gochi-boilerplate/internal/tracing"
+	"log/slog"
+	"net/mail"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
-// LoadConfig memuat variabel lingkungan dari file .env
-func LoadConfig() {
-	err := godotenv.Load()
+// minJWTSecretLength adalah panjang minimum JWT_SECRET (256 bit untuk HS256)
+const minJWTSecretLength = 32
+
+// insecureJWTSecrets adalah nilai secret yang sudah diketahui publik dan tidak boleh dipakai
+var insecureJWTSecrets = []string{"supersecret", "secret", "changeme"}
+
+// Config berisi seluruh konfigurasi aplikasi yang dibaca satu kali saat startup
+type Config struct {
+	DatabaseURL        string
+	MigrateOnStart     bool // Jalankan migrasi up saat server start (aman untuk banyak replika)
+	RequireIfMatch     bool // Tolak perubahan produk tanpa header If-Match dengan 428
+	RevocationCacheTTL time.Duration
+	Server             ServerConfig
+	Log                LogConfig
+	Tracing            TracingConfig
+	RateLimit          RateLimitConfig
+	Lockout            model.LockoutPolicy
+	Account            AccountConfig
+	Trash              TrashConfig
+	Mail               MailConfig
+	JWT                JWTConfig
+}
+
+// AccountConfig berisi pengaturan verifikasi email dan reset password
+type AccountConfig struct {
+	AppURL                   string // URL aplikasi klien untuk tautan di email, misal https://app.example.com
+	RequireEmailVerification bool   // Tolak login jika email belum diverifikasi
+	EmailVerificationTTL     time.Duration
+	PasswordResetTTL         time.Duration
+}
+
+// TrashConfig berisi pengaturan trash produk (soft delete)
+type TrashConfig struct {
+	RetentionDays int           // Produk di trash dihapus permanen setelah sekian hari; 0 mematikan purge
+	PurgeInterval time.Duration // Jeda antar pengecekan purge
+}
+
+// Retention mengembalikan lama produk disimpan di trash sebelum di-purge
+func (c TrashConfig) Retention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// MailConfig berisi pengaturan pengiriman email
+type MailConfig struct {
+	Driver       string // "log" (default), "file", atau "smtp"
+	From         string
+	FileDir      string // Folder tujuan untuk driver "file"
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// RateLimitConfig berisi batas request per grup rute
+type RateLimitConfig struct {
+	Enabled bool
+	Auth    model.RateLimit // Per IP untuk rute /auth publik selain yang mengirim email
+	Mail    model.RateLimit // Per IP untuk rute /auth yang mengirim email (lupa password, kirim ulang verifikasi)
+	API     model.RateLimit // Per pengguna untuk rute terproteksi
+}
+
+// TracingConfig berisi konfigurasi OpenTelemetry tracing. Endpoint OTLP diatur lewat
+// variabel standar OTEL_EXPORTER_OTLP_ENDPOINT.
+type TracingConfig struct {
+	Exporter    string  // "none" (default), "stdout", atau "otlp"
+	ServiceName string  // Nama layanan pada setiap span
+	SampleRatio float64 // Rasio sampling trace baru (0..1); trace dari gateway mengikuti keputusan parent
+}
+
+// Enabled menandakan tracing aktif
+func (c TracingConfig) Enabled() bool {
+	return c.Exporter != "" && c.Exporter != tracing.ExporterNone
+}
+
+// LogConfig berisi level dan format log aplikasi
+type LogConfig struct {
+	Level  slog.Level
+	Format string // "json" (default) atau "text" untuk development
+}
+
+// ServerConfig berisi konfigurasi http.Server dan siklus hidupnya
+type ServerConfig struct {
+	Port              string
+	ReadTimeout       time.Duration // Batas waktu membaca seluruh request, termasuk body
+	ReadHeaderTimeout time.Duration // Batas waktu membaca header, melindungi dari slowloris
+	WriteTimeout      time.Duration // Batas waktu menulis respon
+	IdleTimeout       time.Duration // Batas waktu koneksi keep-alive yang menganggur
+	MaxHeaderBytes    int
+	ShutdownDelay     time.Duration // Jeda antara readiness gagal dan listener ditutup saat shutdown
+	ShutdownTimeout   time.Duration // Batas waktu menunggu request yang sedang berjalan saat shutdown
+	TLSCertFile       string        // TLS aktif jika cert dan key diisi
+	TLSKeyFile        string
+}
+
+// TLSEnabled menandakan server dijalankan dengan HTTPS
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// JWTConfig berisi konfigurasi penerbitan dan validasi token
+type JWTConfig struct {
+	Secret          string   // Dipakai untuk HS256 jika tidak ada kunci privat
+	PrivateKeyFiles []string // Kunci pertama dipakai untuk menandatangani token
+	PublicKeyFiles  []string // Kunci lama yang hanya dipakai untuk verifikasi
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	ImpersonationTokenTTL time.Duration // Masa berlaku token impersonasi yang diterbitkan admin
+}
+
+// LoadDatabaseURL hanya membaca DATABASE_URL, untuk perintah seperti "migrate" yang tidak
+// membutuhkan JWT, mail, maupun pengaturan server lainnya
+func LoadDatabaseURL() (string, error) {
+	loadDotEnv()
+
+	databaseURL := GetEnv("DATABASE_URL", "")
+	if databaseURL == "" {
+		return "", errors.New("DATABASE_URL is required")
+	}
+	return databaseURL, nil
+}
+
+// loadDotEnv memuat variabel lingkungan dari file .env jika ada
+func loadDotEnv() {
+	if err := godotenv.Load(); err != nil {
+		slog.Warn("File .env tidak ditemukan, menggunakan variabel lingkungan sistem")
+	}
+}
+
+// LoadConfig memuat variabel lingkungan dari file .env, membaca seluruh konfigurasi,
+// lalu memvalidasinya. Semua masalah dikumpulkan dan dikembalikan sekaligus.
+func LoadConfig() (*Config, error) {
+	loadDotEnv()
+
+	var problems []error
+	duration := func(key string, fallback time.Duration) time.Duration {
+		d, err := GetEnvDuration(key, fallback)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		return d
+	}
+
+	boolean := func(key string, fallback bool) bool {
+		b, err := GetEnvBool(key, fallback)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		return b
+	}
+
+	integer := func(key string, fallback int) int {
+		n, err := GetEnvInt(key, fallback)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		return n
+	}
+
+	decimal := func(key string, fallback float64) float64 {
+		f, err := GetEnvFloat(key, fallback)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		return f
+	}
+
+	rateLimit := func(key, fallback string) model.RateLimit {
+		l, err := model.ParseRateLimit(GetEnv(key, fallback))
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", key, err))
+		}
+		return l
+	}
+
+	logLevel, err := logging.ParseLevel(GetEnv("LOG_LEVEL", "info"))
 	if err != nil {
-		log.Println("Peringatan: file .env tidak ditemukan, menggunakan variabel lingkungan sistem")
+		problems = append(problems, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+
+	cfg := &Config{
+		DatabaseURL:        GetEnv("DATABASE_URL", ""),
+		MigrateOnStart:     boolean("MIGRATE_ON_START", false),
+		RequireIfMatch:     boolean("REQUIRE_IF_MATCH", false),
+		RevocationCacheTTL: duration("REVOCATION_CACHE_TTL", 30*time.Second),
+		Server: ServerConfig{
+			Port:              GetEnv("SERVER_PORT", "8080"),
+			ReadTimeout:       duration("SERVER_READ_TIMEOUT", 15*time.Second),
+			ReadHeaderTimeout: duration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+			WriteTimeout:      duration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			IdleTimeout:       duration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			MaxHeaderBytes:    integer("SERVER_MAX_HEADER_BYTES", 1<<20),
+			ShutdownDelay:     duration("SERVER_SHUTDOWN_DELAY", 0),
+			ShutdownTimeout:   duration("SERVER_SHUTDOWN_TIMEOUT", 20*time.Second),
+			TLSCertFile:       GetEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:        GetEnv("TLS_KEY_FILE", ""),
+		},
+		Log: LogConfig{
+			Level:  logLevel,
+			Format: GetEnv("LOG_FORMAT", logging.FormatJSON),
+		},
+		Tracing: TracingConfig{
+			Exporter:    GetEnv("TRACING_EXPORTER", tracing.ExporterNone),
+			ServiceName: GetEnv("OTEL_SERVICE_NAME", "gochi-boilerplate"),
+			SampleRatio: decimal("TRACING_SAMPLE_RATIO", 1),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: boolean("RATE_LIMIT_ENABLED", true),
+			Auth:    rateLimit("RATE_LIMIT_AUTH", "10/1m"),
+			Mail:    rateLimit("RATE_LIMIT_MAIL", "3/10m"),
+			API:     rateLimit("RATE_LIMIT_API", "300/1m"),
+		},
+		Lockout: model.LockoutPolicy{
+			Threshold: integer("LOGIN_LOCKOUT_THRESHOLD", 5),
+			Base:      duration("LOGIN_LOCKOUT_BASE", time.Minute),
+			Max:       duration("LOGIN_LOCKOUT_MAX", time.Hour),
+		},
+		Account: AccountConfig{
+			AppURL:                   strings.TrimRight(GetEnv("APP_URL", "http://localhost:3000"), "/"),
+			RequireEmailVerification: boolean("REQUIRE_EMAIL_VERIFICATION", true),
+			EmailVerificationTTL:     duration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			PasswordResetTTL:         duration("PASSWORD_RESET_TTL", time.Hour),
+		},
+		Trash: TrashConfig{
+			RetentionDays: integer("TRASH_RETENTION_DAYS", 30),
+			PurgeInterval: duration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		Mail: MailConfig{
+			Driver:       GetEnv("MAIL_DRIVER", "log"),
+			From:         GetEnv("MAIL_FROM", "no-reply@localhost"),
+			FileDir:      GetEnv("MAIL_FILE_DIR", "tmp/mail"),
+			SMTPHost:     GetEnv("SMTP_HOST", ""),
+			SMTPPort:     integer("SMTP_PORT", 587),
+			SMTPUsername: GetEnv("SMTP_USERNAME", ""),
+			SMTPPassword: GetEnv("SMTP_PASSWORD", ""),
+		},
+		JWT: JWTConfig{
+			Secret:          GetEnv("JWT_SECRET", ""),
+			PrivateKeyFiles: GetEnvList("JWT_PRIVATE_KEY_FILES"),
+			PublicKeyFiles:  GetEnvList("JWT_PUBLIC_KEY_FILES"),
+			AccessTokenTTL:  duration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL: duration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+			ImpersonationTokenTTL: duration("IMPERSONATION_TOKEN_TTL", 15*time.Minute),
+		},
+	}
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	return cfg, nil
+}
+
+// validate memeriksa nilai konfigurasi dan mengembalikan daftar semua masalah yang ditemukan
+func (c *Config) validate() []error {
+	var problems []error
+
+	if c.DatabaseURL == "" {
+		problems = append(problems, errors.New("DATABASE_URL is required"))
+	}
+
+	if c.RevocationCacheTTL < 0 {
+		problems = append(problems, errors.New("REVOCATION_CACHE_TTL must not be negative"))
+	}
+
+	if c.Log.Format != logging.FormatJSON && c.Log.Format != logging.FormatText {
+		problems = append(problems, fmt.Errorf("LOG_FORMAT must be %q or %q, got %q", logging.FormatJSON, logging.FormatText, c.Log.Format))
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		problems = append(problems, fmt.Errorf("TRACING_EXPORTER must be one of none, stdout or otlp, got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, errors.New("TRACING_SAMPLE_RATIO must be between 0 and 1"))
+	}
+
+	if c.Lockout.Threshold < 1 {
+		problems = append(problems, errors.New("LOGIN_LOCKOUT_THRESHOLD must be at least 1"))
+	}
+	if c.Lockout.Base <= 0 || c.Lockout.Max < c.Lockout.Base {
+		problems = append(problems, errors.New("LOGIN_LOCKOUT_BASE must be positive and not greater than LOGIN_LOCKOUT_MAX"))
 	}
+
+	if u, err := url.Parse(c.Account.AppURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, fmt.Errorf("APP_URL must be an absolute http(s) URL, got %q", c.Account.AppURL))
+	}
+	if c.Account.EmailVerificationTTL <= 0 || c.Account.PasswordResetTTL <= 0 {
+		problems = append(problems, errors.New("EMAIL_VERIFICATION_TTL and PASSWORD_RESET_TTL must be positive"))
+	}
+
+	if c.Trash.RetentionDays < 0 {
+		problems = append(problems, errors.New("TRASH_RETENTION_DAYS must not be negative"))
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		problems = append(problems, errors.New("TRASH_PURGE_INTERVAL must be positive"))
+	}
+
+	problems = append(problems, c.Server.validate()...)
+	problems = append(problems, c.Mail.validate()...)
+	return append(problems, c.JWT.validate()...)
+}
+
+func (c *MailConfig) validate() []error {
+	var problems []error
+
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		problems = append(problems, fmt.Errorf("MAIL_FROM is not a valid address: %q", c.From))
+	}
+	switch c.Driver {
+	case "log":
+	case "file":
+		if c.FileDir == "" {
+			problems = append(problems, errors.New("MAIL_FILE_DIR is required when MAIL_DRIVER is file"))
+		}
+	case "smtp":
+		if c.SMTPHost == "" {
+			problems = append(problems, errors.New("SMTP_HOST is required when MAIL_DRIVER is smtp"))
+		}
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			problems = append(problems, fmt.Errorf("SMTP_PORT must be between 1 and 65535, got %d", c.SMTPPort))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("MAIL_DRIVER must be one of log, file or smtp, got %q", c.Driver))
+	}
+
+	return problems
+}
+
+func (c *ServerConfig) validate() []error {
+	var problems []error
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Errorf("SERVER_PORT must be a number between 1 and 65535, got %q", c.Port))
+	}
+
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", c.ReadTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value <= 0 {
+			problems = append(problems, fmt.Errorf("%s must be positive", t.key))
+		}
+	}
+	if c.ShutdownDelay < 0 {
+		problems = append(problems, errors.New("SERVER_SHUTDOWN_DELAY must not be negative"))
+	}
+	if c.MaxHeaderBytes < 1024 {
+		problems = append(problems, errors.New("SERVER_MAX_HEADER_BYTES must be at least 1024"))
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		problems = append(problems, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	for _, path := range []string{c.TLSCertFile, c.TLSKeyFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			problems = append(problems, fmt.Errorf("TLS file %q is not readable: %w", path, err))
+		}
+	}
+
+	return problems
+}
+
+func (c *JWTConfig) validate() []error {
+	var problems []error
+
+	// Secret hanya wajib jika token ditandatangani dengan HS256 (tanpa kunci privat)
+	if len(c.PrivateKeyFiles) == 0 {
+		switch {
+		case c.Secret == "":
+			problems = append(problems, errors.New("JWT_SECRET is required when JWT_PRIVATE_KEY_FILES is not set"))
+		case isInsecureSecret(c.Secret):
+			problems = append(problems, errors.New("JWT_SECRET uses a well-known default value, generate a random one (e.g. openssl rand -base64 48)"))
+		case len(c.Secret) < minJWTSecretLength:
+			problems = append(problems, fmt.Errorf("JWT_SECRET must be at least %d characters, got %d", minJWTSecretLength, len(c.Secret)))
+		}
+		if len(c.PublicKeyFiles) > 0 {
+			problems = append(problems, errors.New("JWT_PUBLIC_KEY_FILES requires JWT_PRIVATE_KEY_FILES to be set"))
+		}
+	}
+
+	for _, path := range append(append([]string{}, c.PrivateKeyFiles...), c.PublicKeyFiles...) {
+		if _, err := os.Stat(path); err != nil {
+			problems = append(problems, fmt.Errorf("JWT key file %q is not readable: %w", path, err))
+		}
+	}
+
+	if c.AccessTokenTTL <= 0 {
+		problems = append(problems, errors.New("ACCESS_TOKEN_TTL must be positive"))
+	}
+	if c.RefreshTokenTTL <= c.AccessTokenTTL {
+		problems = append(problems, errors.New("REFRESH_TOKEN_TTL must be longer than ACCESS_TOKEN_TTL"))
+	}
+	if c.ImpersonationTokenTTL <= 0 {
+		problems = append(problems, errors.New("IMPERSONATION_TOKEN_TTL must be positive"))
+	}
+
+	return problems
+}
+
+func isInsecureSecret(secret string) bool {
+	for _, s := range insecureJWTSecrets {
+		if strings.EqualFold(secret, s) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetEnv mengambil variabel lingkungan atau mengembalikan nilai default
@@ -21,4 +444,71 @@ func GetEnv(key, fallback string) string {
 		return value
 	}
 	return fallback
-}
\ No newline at end of file
+}
+
+// GetEnvDuration mengambil variabel lingkungan berformat durasi (misal: "15m", "720h")
+// atau mengembalikan nilai default jika tidak diatur
+func GetEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback, fmt.Errorf("%s must be a duration such as 15m or 720h, got %q", key, value)
+	}
+	return d, nil
+}
+
+// GetEnvBool mengambil variabel lingkungan bernilai boolean (true/false/1/0)
+// atau mengembalikan nilai default jika tidak diatur
+func GetEnvBool(key string, fallback bool) (bool, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback, fmt.Errorf("%s must be true or false, got %q", key, value)
+	}
+	return b, nil
+}
+
+// GetEnvInt mengambil variabel lingkungan bernilai bilangan bulat
+// atau mengembalikan nilai default jika tidak diatur
+func GetEnvInt(key string, fallback int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback, fmt.Errorf("%s must be an integer, got %q", key, value)
+	}
+	return n, nil
+}
+
+// GetEnvFloat mengambil variabel lingkungan bernilai desimal
+// atau mengembalikan nilai default jika tidak diatur
+func GetEnvFloat(key string, fallback float64) (float64, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fallback, fmt.Errorf("%s must be a number, got %q", key, value)
+	}
+	return f, nil
+}
+
+// GetEnvList mengambil variabel lingkungan berisi daftar yang dipisah koma
+func GetEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(GetEnv(key, ""), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
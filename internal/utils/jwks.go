@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK adalah representasi JSON Web Key (RFC 7517) untuk kunci publik
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // Kurva OKP (Ed25519)
+	X   string `json:"x,omitempty"`   // Kunci publik OKP
+}
+
+// JWKS adalah kumpulan JWK yang dipublikasikan di /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// signingKey adalah satu kunci asimetris untuk menandatangani dan/atau memverifikasi JWT
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer // nil jika kunci hanya dipakai untuk verifikasi (kunci lama saat rotasi)
+	public  crypto.PublicKey
+}
+
+// parsePrivateKey membaca kunci privat RSA atau Ed25519 dari PEM (PKCS#8 atau PKCS#1)
+func parsePrivateKey(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM data")
+	}
+
+	var parsed any
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("unsupported private key: %w", err)
+		}
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	key, err := newSigningKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.private = signer
+	return key, nil
+}
+
+// parsePublicKey membaca kunci publik RSA atau Ed25519 dari PEM (PKIX)
+func parsePublicKey(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM data")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported public key: %w", err)
+	}
+	return newSigningKey(pub)
+}
+
+// newSigningKey menentukan algoritma dari tipe kunci dan menghitung kid-nya
+func newSigningKey(pub crypto.PublicKey) (*signingKey, error) {
+	key := &signingKey{public: pub}
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 are supported", pub)
+	}
+
+	kid, err := thumbprint(key.jwk())
+	if err != nil {
+		return nil, err
+	}
+	key.kid = kid
+	return key, nil
+}
+
+// jwk mengubah kunci publik menjadi JWK (tanpa kid jika belum dihitung)
+func (k *signingKey) jwk() JWK {
+	jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// thumbprint menghitung JWK thumbprint (RFC 7638) yang dipakai sebagai kid,
+// sehingga kid selalu sama untuk kunci yang sama tanpa perlu dikonfigurasi
+func thumbprint(jwk JWK) (string, error) {
+	// Hanya anggota wajib, diurutkan secara leksikografis sesuai RFC 7638
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
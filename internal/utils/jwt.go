@@ -2,53 +2,155 @@ package utils
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// Claims adalah struktur custom untuk data di dalam token JWT
+// Claims adalah struktur custom untuk data di dalam token JWT.
+// Klaim jti (RegisteredClaims.ID) unik per token dan dipakai untuk mencabut token saat logout.
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID         string `json:"user_id"`
+	Role           string `json:"role"`
+	ImpersonatedBy string `json:"impersonated_by,omitempty"` // ID admin yang menerbitkan token atas nama pengguna ini
 	jwt.RegisteredClaims
 }
 
-// GenerateToken membuat token JWT baru untuk pengguna
-func GenerateToken(userID, role string) (string, error) {
-	jwtSecret := GetEnv("JWT_SECRET", "supersecret")
-	
-	claims := &Claims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)), // Token berlaku 24 jam
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", err
+// IsImpersonation menandakan token diterbitkan oleh admin untuk bertindak sebagai pengguna lain
+func (c *Claims) IsImpersonation() bool {
+	return c.ImpersonatedBy != ""
+}
+
+// TokenManager menerbitkan dan memvalidasi access token (JWT).
+//
+// Jika kunci privat RSA/Ed25519 dikonfigurasi, token ditandatangani dengan kunci aktif (RS256/EdDSA)
+// dan header kid diisi, sehingga layanan lain bisa memverifikasi lewat JWKS tanpa berbagi secret.
+// Kunci lain tetap diterima untuk verifikasi selama masa rotasi. Tanpa kunci asimetris,
+// token ditandatangani dengan HS256 memakai secret dari konfigurasi.
+type TokenManager struct {
+	accessTTL time.Duration
+	secret    []byte                 // Hanya dipakai pada mode HS256
+	active    *signingKey            // Kunci untuk menandatangani token baru
+	keys      map[string]*signingKey // Semua kunci yang diterima untuk verifikasi, berdasarkan kid
+	order     []string               // Urutan kid untuk JWKS
+}
+
+// NewTokenManager membuat TokenManager. privateKeys berisi PEM kunci privat; kunci pertama menjadi
+// kunci aktif untuk menandatangani. publicKeys berisi PEM kunci publik yang hanya dipakai untuk verifikasi.
+func NewTokenManager(secret string, accessTTL time.Duration, privateKeys, publicKeys [][]byte) (*TokenManager, error) {
+	m := &TokenManager{
+		accessTTL: accessTTL,
+		secret:    []byte(secret),
+		keys:      make(map[string]*signingKey),
 	}
 
-	return tokenString, nil
+	for i, data := range privateKeys {
+		key, err := parsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("private key #%d: %w", i+1, err)
+		}
+		if i == 0 {
+			m.active = key
+		}
+		m.addKey(key)
+	}
+	for i, data := range publicKeys {
+		key, err := parsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("public key #%d: %w", i+1, err)
+		}
+		m.addKey(key)
+	}
+
+	if m.active == nil && len(m.keys) > 0 {
+		return nil, fmt.Errorf("public keys configured without a private signing key")
+	}
+	return m, nil
 }
 
-// ValidateToken memvalidasi token JWT dan mengembalikan claims
-func ValidateToken(tokenString string) (*Claims, error) {
-	jwtSecret := GetEnv("JWT_SECRET", "supersecret")
+// NewTokenManagerFromConfig membuat TokenManager dari konfigurasi JWT, membaca file-file kunci PEM
+func NewTokenManagerFromConfig(cfg JWTConfig) (*TokenManager, error) {
+	privateKeys, err := readKeyFiles(cfg.PrivateKeyFiles)
+	if err != nil {
+		return nil, err
+	}
+	publicKeys, err := readKeyFiles(cfg.PublicKeyFiles)
+	if err != nil {
+		return nil, err
+	}
+	return NewTokenManager(cfg.Secret, cfg.AccessTokenTTL, privateKeys, publicKeys)
+}
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Pastikan metode signing adalah HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// readKeyFiles membaca isi file-file PEM
+func readKeyFiles(paths []string) ([][]byte, error) {
+	var keys [][]byte
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read key file: %w", err)
 		}
-		return []byte(jwtSecret), nil
-	})
+		keys = append(keys, data)
+	}
+	return keys, nil
+}
+
+func (m *TokenManager) addKey(key *signingKey) {
+	if _, exists := m.keys[key.kid]; exists {
+		return
+	}
+	m.keys[key.kid] = key
+	m.order = append(m.order, key.kid)
+}
+
+// AccessTokenTTL mengembalikan masa berlaku access token
+func (m *TokenManager) AccessTokenTTL() time.Duration {
+	return m.accessTTL
+}
+
+// GenerateToken membuat token JWT baru untuk pengguna
+func (m *TokenManager) GenerateToken(userID, role string) (string, error) {
+	token, _, err := m.sign(&Claims{UserID: userID, Role: role}, m.accessTTL) // Access token berumur pendek, diperbarui lewat refresh token
+	return token, err
+}
+
+// GenerateImpersonationToken membuat access token atas nama pengguna lain untuk admin dengan ID adminID.
+// Token ini tidak disertai refresh token, jadi sesi impersonasi berakhir saat token kedaluwarsa.
+// Claims dikembalikan agar jti dan waktu kedaluwarsanya bisa dicatat di audit log.
+func (m *TokenManager) GenerateImpersonationToken(userID, role, adminID string, ttl time.Duration) (string, *Claims, error) {
+	return m.sign(&Claims{UserID: userID, Role: role, ImpersonatedBy: adminID}, ttl)
+}
+
+// sign melengkapi registered claims (jti, exp, iat, nbf) lalu menandatangani token dengan kunci aktif
+func (m *TokenManager) sign(claims *Claims, ttl time.Duration) (string, *Claims, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
 
+	var token *jwt.Token
+	var key interface{}
+	if m.active == nil {
+		token, key = jwt.NewWithClaims(jwt.SigningMethodHS256, claims), m.secret
+	} else {
+		token, key = jwt.NewWithClaims(m.active.method, claims), m.active.private
+		token.Header["kid"] = m.active.kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// ValidateToken memvalidasi token JWT dan mengembalikan claims
+func (m *TokenManager) ValidateToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.keyFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +158,38 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
-	
+
 	return nil, fmt.Errorf("invalid token")
-}
\ No newline at end of file
+}
+
+// keyFunc memilih kunci verifikasi berdasarkan kid dan memastikan algoritma token
+// sesuai dengan tipe kuncinya, untuk mencegah serangan algorithm confusion
+func (m *TokenManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	if m.active == nil {
+		// Pastikan metode signing adalah HMAC
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return m.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// JWKS mengembalikan semua kunci publik yang diterima, untuk dipublikasikan ke layanan lain.
+// Pada mode HS256 hasilnya kosong karena secret tidak boleh dipublikasikan.
+func (m *TokenManager) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, kid := range m.order {
+		set.Keys = append(set.Keys, m.keys[kid].jwk())
+	}
+	return set
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// Pagination adalah metadata paginasi yang disertakan pada respon daftar.
+// Mode keyset mengisi next_cursor, mode offset mengisi page, per_page, total dan total_pages.
+type Pagination struct {
+	Limit      int    `json:"limit" example:"20"`
+	HasMore    bool   `json:"has_more" example:"true"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJjcmVhdGVkX2F0Ijoi..."`
+	Page       int    `json:"page,omitempty" example:"1"`
+	PerPage    int    `json:"per_page,omitempty" example:"20"`
+	Total      *int64 `json:"total,omitempty" example:"42"`
+	TotalPages int    `json:"total_pages,omitempty" example:"3"`
+}
+
+// EncodeCursor mengubah posisi paginasi keyset menjadi string opaque yang aman untuk URL
+func EncodeCursor(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor membaca kembali cursor yang dibuat oleh EncodeCursor ke dalam v
+func DecodeCursor(cursor string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
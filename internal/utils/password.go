@@ -1,15 +1,26 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"gochi-boilerplate/internal/metrics"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptCost adalah cost factor bcrypt untuk hash baru. Test boleh menurunkannya (misal ke
+// bcrypt.MinCost) agar cepat; hash yang sudah tersimpan tetap diverifikasi dengan cost-nya sendiri.
+var BcryptCost = 14
 
 // HashPassword menghasilkan hash dari sebuah password menggunakan bcrypt
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14) // Angka 14 adalah cost factor
+	defer metrics.ObservePasswordHash("hash", time.Now())
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
 	return string(bytes), err
 }
 
 // CheckPasswordHash membandingkan password dengan hash-nya
 func CheckPasswordHash(password, hash string) bool {
+	defer metrics.ObservePasswordHash("compare", time.Now())
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil // Jika tidak ada error, password cocok
-}
\ No newline at end of file
+}
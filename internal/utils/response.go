@@ -2,15 +2,24 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/repository"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // Response adalah struktur standar untuk semua respon API JSON
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`  // omitempty agar tidak muncul jika nil
-	Error   string      `json:"error,omitempty"` // omitempty agar tidak muncul jika nil
+	Success    bool              `json:"success"`
+	Message    string            `json:"message"`
+	Data       interface{}       `json:"data,omitempty"`       // omitempty agar tidak muncul jika nil
+	Pagination *Pagination       `json:"pagination,omitempty"` // Hanya ada pada respon berupa daftar
+	Code       string            `json:"code,omitempty"`       // Kode error yang stabil, misal "not_found"
+	Error      string            `json:"error,omitempty"`      // omitempty agar tidak muncul jika nil
+	Fields     map[string]string `json:"fields,omitempty"`     // Pesan error per field pada kegagalan validasi
 }
 
 // writeJSON adalah helper internal untuk menulis respon JSON
@@ -30,6 +39,17 @@ func RespondSuccess(w http.ResponseWriter, statusCode int, message string, data
 	writeJSON(w, statusCode, resp)
 }
 
+// RespondPaginated mengirimkan respon sukses berupa daftar beserta metadata paginasi
+func RespondPaginated(w http.ResponseWriter, statusCode int, message string, data interface{}, pagination *Pagination) {
+	resp := Response{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Pagination: pagination,
+	}
+	writeJSON(w, statusCode, resp)
+}
+
 // RespondError mengirimkan respon error (HTTP 400-599)
 func RespondError(w http.ResponseWriter, statusCode int, message string, err string) {
 	resp := Response{
@@ -38,4 +58,116 @@ func RespondError(w http.ResponseWriter, statusCode int, message string, err str
 		Error:   err,
 	}
 	writeJSON(w, statusCode, resp)
-}
\ No newline at end of file
+}
+
+// Kode error yang stabil dan bisa dibaca mesin, dikirim pada field "code"
+const (
+	ErrCodeInvalidBody = "invalid_body"
+	ErrCodeBodyTooBig  = "body_too_large"
+	ErrCodeValidation  = "validation_failed"
+	ErrCodeNotFound    = "not_found"
+	ErrCodeConflict    = "conflict"
+	ErrCodeConstraint  = "constraint_violation"
+	ErrCodeUnavailable = "service_unavailable"
+	ErrCodeRateLimited = "rate_limited"
+	ErrCodeLocked      = "account_locked"
+	ErrCodeInternal    = "internal_error"
+
+	ErrCodePreconditionFailed   = "precondition_failed"
+	ErrCodePreconditionRequired = "precondition_required"
+
+	ErrCodeInvalidPatch    = "invalid_patch"
+	ErrCodePatchTestFailed = "patch_test_failed"
+)
+
+// RateLimitError dikembalikan saat klien melebihi batas request atau akunnya sedang dikunci.
+// RespondErr memetakannya ke 429 dengan header Retry-After.
+type RateLimitError struct {
+	RetryAfter    time.Duration
+	AccountLocked bool // true jika penyebabnya penguncian akun, bukan batas request
+}
+
+func (e *RateLimitError) Error() string {
+	if e.AccountLocked {
+		return fmt.Sprintf("account locked, retry after %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// PreconditionError dikembalikan saat header If-Match tidak dikirim padahal wajib (428), atau tidak
+// cocok dengan ETag data saat ini (412)
+type PreconditionError struct {
+	Required bool // true jika If-Match wajib tetapi tidak dikirim
+}
+
+func (e *PreconditionError) Error() string {
+	if e.Required {
+		return "If-Match header is required"
+	}
+	return "If-Match does not match the current ETag"
+}
+
+// PatchError dikembalikan saat dokumen PATCH valid sebagai JSON tetapi tidak bisa diterapkan:
+// operasi tidak dikenal, path tidak ada, hasil tidak berbentuk data yang diharapkan (422), atau
+// operasi "test" JSON Patch tidak cocok dengan data saat ini (409)
+type PatchError struct {
+	TestFailed bool
+	Err        error
+}
+
+func (e *PatchError) Error() string { return e.Err.Error() }
+func (e *PatchError) Unwrap() error { return e.Err }
+
+// RespondErr memetakan error dari repository, decoding body, dan validasi ke status HTTP
+// dan kode error yang sesuai. Ini satu-satunya tempat pemetaan error domain ke HTTP,
+// jadi handler tidak perlu menebak status.
+func RespondErr(w http.ResponseWriter, err error) {
+	status, code, message := http.StatusInternalServerError, ErrCodeInternal, "Terjadi kesalahan pada server"
+	var fields map[string]string
+	var bodyErr *BodyError
+	var validationErr *ValidationError
+	var rateLimitErr *RateLimitError
+	var preconditionErr *PreconditionError
+	var patchErr *PatchError
+	switch {
+	case errors.As(err, &validationErr):
+		status, code, message = http.StatusUnprocessableEntity, ErrCodeValidation, "Data tidak valid"
+		fields = validationErr.Fields
+	case errors.As(err, &bodyErr) && bodyErr.TooLarge:
+		status, code, message = http.StatusRequestEntityTooLarge, ErrCodeBodyTooBig, "Request body terlalu besar"
+	case errors.As(err, &bodyErr):
+		status, code, message = http.StatusBadRequest, ErrCodeInvalidBody, "Request body tidak valid"
+	case errors.As(err, &rateLimitErr):
+		status, code, message = http.StatusTooManyRequests, ErrCodeRateLimited, "Terlalu banyak request, coba lagi nanti"
+		if rateLimitErr.AccountLocked {
+			code, message = ErrCodeLocked, "Akun dikunci sementara karena terlalu banyak percobaan login gagal"
+		}
+		// Retry-After dalam detik, dibulatkan ke atas agar klien tidak mencoba terlalu cepat
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+	case errors.As(err, &patchErr) && patchErr.TestFailed:
+		status, code, message = http.StatusConflict, ErrCodePatchTestFailed, "Operasi test pada patch tidak cocok dengan data saat ini"
+	case errors.As(err, &patchErr):
+		status, code, message = http.StatusUnprocessableEntity, ErrCodeInvalidPatch, "Patch tidak bisa diterapkan"
+	case errors.As(err, &preconditionErr) && preconditionErr.Required:
+		status, code, message = http.StatusPreconditionRequired, ErrCodePreconditionRequired, "Header If-Match wajib dikirim"
+	case errors.As(err, &preconditionErr), errors.Is(err, repository.ErrStale):
+		status, code, message = http.StatusPreconditionFailed, ErrCodePreconditionFailed, "Data sudah diubah oleh pihak lain, muat ulang lalu coba lagi"
+	case errors.Is(err, repository.ErrNotFound):
+		status, code, message = http.StatusNotFound, ErrCodeNotFound, "Data tidak ditemukan"
+	case errors.Is(err, repository.ErrConflict):
+		status, code, message = http.StatusConflict, ErrCodeConflict, "Data sudah ada"
+	case errors.Is(err, repository.ErrConstraint):
+		status, code, message = http.StatusUnprocessableEntity, ErrCodeConstraint, "Data tidak memenuhi aturan yang berlaku"
+	case errors.Is(err, repository.ErrUnavailable):
+		status, code, message = http.StatusServiceUnavailable, ErrCodeUnavailable, "Layanan sedang tidak tersedia, coba lagi nanti"
+	}
+
+	resp := Response{
+		Success: false,
+		Message: message,
+		Code:    code,
+		Error:   err.Error(),
+		Fields:  fields,
+	}
+	writeJSON(w, status, resp)
+}
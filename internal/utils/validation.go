@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gochi-boilerplate/internal/model"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxBodyBytes adalah ukuran maksimum body request JSON (1 MiB)
+const MaxBodyBytes = 1 << 20
+
+// ErrEmptyBody dikembalikan DecodeJSON jika body request kosong
+var ErrEmptyBody = errors.New("request body is empty")
+
+// BodyError menandakan body request tidak bisa dibaca: JSON rusak, field tidak dikenal, atau terlalu besar
+type BodyError struct {
+	TooLarge bool
+	Err      error
+}
+
+func (e *BodyError) Error() string { return e.Err.Error() }
+func (e *BodyError) Unwrap() error { return e.Err }
+
+// ValidationError berisi pesan error per field (berdasarkan nama field JSON)
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for field, msg := range e.Fields {
+		parts = append(parts, field+" "+msg)
+	}
+	sort.Strings(parts)
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Pakai nama field JSON pada pesan error agar sesuai dengan yang dikirim klien
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	// notblank menolak string yang hanya berisi spasi
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+
+	// maxbytes membatasi panjang string dalam byte, bukan karakter seperti max. Dipakai untuk password
+	// karena bcrypt menolak input lebih dari 72 byte, dan satu karakter multibyte bisa memakan hingga 4 byte.
+	v.RegisterValidation("maxbytes", func(fl validator.FieldLevel) bool {
+		limit, err := strconv.Atoi(fl.Param())
+		if err != nil {
+			panic(fmt.Sprintf("maxbytes: invalid parameter %q", fl.Param()))
+		}
+		return len(fl.Field().String()) <= limit
+	})
+
+	// role hanya menerima peran yang terdaftar di model.RolePermissions, sehingga peran baru
+	// tidak perlu ditambahkan lagi di tag validasi
+	v.RegisterValidation("role", func(fl validator.FieldLevel) bool {
+		_, ok := model.RolePermissions[fl.Field().String()]
+		return ok
+	})
+	return v
+}
+
+// DecodeJSON membaca body request JSON ke dst dengan batas ukuran MaxBodyBytes,
+// menolak field yang tidak dikenal dan data tambahan setelah objek JSON pertama
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, io.EOF):
+			return ErrEmptyBody
+		case errors.As(err, &maxBytesErr):
+			return &BodyError{TooLarge: true, Err: fmt.Errorf("request body must not be larger than %d bytes", maxBytesErr.Limit)}
+		default:
+			return &BodyError{Err: err}
+		}
+	}
+	if dec.More() {
+		return &BodyError{Err: errors.New("request body must contain a single JSON object")}
+	}
+	return nil
+}
+
+// ReadBody membaca seluruh body request dengan batas ukuran MaxBodyBytes, untuk body yang
+// perlu diolah sebelum di-decode ke struct (misalnya dokumen patch)
+func ReadBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return nil, &BodyError{TooLarge: true, Err: fmt.Errorf("request body must not be larger than %d bytes", maxBytesErr.Limit)}
+	case err != nil:
+		return nil, &BodyError{Err: err}
+	case len(body) == 0:
+		return nil, &BodyError{Err: ErrEmptyBody}
+	}
+	return body, nil
+}
+
+// Validate menjalankan aturan pada tag `validate` di struct dan mengembalikan *ValidationError jika gagal
+func Validate(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+	verr := &ValidationError{Fields: make(map[string]string, len(fieldErrs))}
+	for _, fe := range fieldErrs {
+		// Namespace tanpa nama struct paling luar, misal "address.city" untuk struct bersarang
+		field := fe.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		verr.Fields[field] = validationMessage(fe)
+	}
+	return verr
+}
+
+// DecodeAndValidate menggabungkan DecodeJSON dan Validate. Body kosong diperlakukan sebagai error.
+func DecodeAndValidate(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	if err := DecodeJSON(w, r, dst); err != nil {
+		if errors.Is(err, ErrEmptyBody) {
+			return &BodyError{Err: err}
+		}
+		return err
+	}
+	return Validate(dst)
+}
+
+// validationMessage membuat pesan error yang mudah dipahami untuk satu aturan yang gagal
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "required_with":
+		return "is required when " + snakeCase(fe.Param()) + " is set"
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "role":
+		return "must be one of: " + strings.Join(model.Roles(), ", ")
+	case "min", "gte":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be greater than or equal to " + fe.Param()
+	case "max", "lte":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be less than or equal to " + fe.Param()
+	case "maxbytes":
+		return "must be at most " + fe.Param() + " bytes"
+	case "nefield":
+		return "must be different from " + snakeCase(fe.Param())
+	}
+	return "is invalid (" + fe.Tag() + ")"
+}
+
+// snakeCase mengubah nama field Go (CurrentPassword) menjadi nama field JSON (current_password)
+// untuk pesan validasi yang merujuk field lain
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}